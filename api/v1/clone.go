@@ -19,6 +19,12 @@ func CloneHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
 		DesId:       cloneRequest.DesId,
 		Concurrency: cloneRequest.Concurrency,
 		Size:        cloneRequest.Size,
+		Verify:      cloneRequest.Verify,
+		Filter:      cloneRequest.Filter,
+		DryRun:      cloneRequest.DryRun,
+		Share:       cloneRequest.Share,
+		Conflict:    cloneRequest.Conflict,
+		Shortcuts:   cloneRequest.Shortcuts,
 	})
 	if err != nil {
 		return ctx.JSON(fiber.Map{
@@ -19,6 +19,12 @@ func DownloadHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) erro
 		LocalDir:    downloadRequest.LocalDir,
 		Concurrency: downloadRequest.Concurrency,
 		Size:        downloadRequest.Size,
+		Verify:      downloadRequest.Verify,
+		Filter:      downloadRequest.Filter,
+		DryRun:      downloadRequest.DryRun,
+		Conflict:    downloadRequest.Conflict,
+		Export:      downloadRequest.ExportOptions,
+		Shortcuts:   downloadRequest.Shortcuts,
 	})
 	if err != nil {
 		return ctx.JSON(fiber.Map{
@@ -0,0 +1,114 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/jaskaranSM/transfer-service/manager"
+	"github.com/jaskaranSM/transfer-service/service/gdrive"
+	"github.com/jaskaranSM/transfer-service/types"
+)
+
+func ListDrivesHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
+	client, err := gdmanager.Client()
+	if err != nil {
+		ctx.SendStatus(500)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	drives, err := client.ListDrives()
+	if err != nil {
+		ctx.SendStatus(500)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.JSON(fiber.Map{
+		"drives": drives,
+	})
+}
+
+func CreateDriveHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
+	var createRequest types.CreateDriveRequest
+	err := ctx.BodyParser(&createRequest)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	client, err := gdmanager.Client()
+	if err != nil {
+		ctx.SendStatus(500)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	created, err := client.CreateDrive(createRequest.Name)
+	if err != nil {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.JSON(fiber.Map{
+		"drive": created,
+	})
+}
+
+// DriveMembersHandler adds or removes the members of a shared drive, the
+// drive may be given by id or as "name:".
+func DriveMembersHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager, remove bool) error {
+	var membersRequest types.DriveMembersRequest
+	err := ctx.BodyParser(&membersRequest)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	client, err := gdmanager.Client()
+	if err != nil {
+		ctx.SendStatus(500)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	err = client.ResolveIds(&membersRequest.DriveId)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	emails := membersRequest.Emails
+	if membersRequest.ServiceAccounts {
+		saEmails, err := client.ServiceAccountEmails()
+		if err != nil {
+			ctx.SendStatus(500)
+			return ctx.JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		emails = append(emails, saEmails...)
+	}
+	if len(emails) == 0 {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": "provide emails or service_accounts, bad request",
+		})
+	}
+	var results []*gdrive.ItemResult
+	if remove {
+		results, err = client.RemoveDriveMembers(membersRequest.DriveId, emails)
+	} else {
+		results, err = client.AddDriveMembers(membersRequest.DriveId, emails, membersRequest.Role)
+	}
+	if err != nil {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.JSON(fiber.Map{
+		"drive_id": membersRequest.DriveId,
+		"results":  results,
+	})
+}
@@ -1,29 +1,40 @@
 package v1
 
 import (
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
+	"google.golang.org/api/drive/v3"
+
 	"github.com/jaskaranSM/transfer-service/manager"
-	"github.com/jaskaranSM/transfer-service/service/gdrive"
 )
 
 func FileMetdataHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
-	fileId := ctx.Params("fileId")
+	fileId, err := fileIdParam(ctx)
 	if fileId == "" {
-		ctx.SendStatus(401)
-		return ctx.JSON(fiber.Map{
-			"error": "provide fileId in param, bad request",
-		})
+		return err
 	}
 
-	client := gdrive.NewGoogleDriveClient(1, 0, nil)
-	err := client.Authorize()
+	client, err := gdmanager.Client()
 	if err != nil {
 		ctx.SendStatus(500)
 		return ctx.JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
-	file, err := client.GetFileMetadata(fileId)
+	err = client.ResolveIds(&fileId)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	var file *drive.File
+	if fields := ctx.Query("fields"); fields != "" {
+		file, err = client.GetFileFields(fileId, strings.Split(fields, ","))
+	} else {
+		file, err = client.GetFileMetadata(fileId)
+	}
 	if err != nil {
 		ctx.SendStatus(404)
 		return ctx.JSON(fiber.Map{
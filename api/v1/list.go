@@ -1,12 +1,27 @@
 package v1
 
 import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+
+	"github.com/jaskaranSM/transfer-service/logging"
 	"github.com/jaskaranSM/transfer-service/manager"
 	"github.com/jaskaranSM/transfer-service/service/gdrive"
 	"github.com/jaskaranSM/transfer-service/types"
 )
 
+const listOutputTree = "tree"
+const listOutputFlat = "flat"
+const listFormatJson = "json"
+const listFormatJsonl = "jsonl"
+const listFormatCsv = "csv"
+
 func ListFilesHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
 	var listFilesRequest types.ListFilesRequest
 	err := ctx.BodyParser(&listFilesRequest)
@@ -16,14 +31,34 @@ func ListFilesHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) err
 		})
 	}
 
-	client := gdrive.NewGoogleDriveClient(1, 0, nil)
-	err = client.Authorize()
+	client, err := gdmanager.Client()
 	if err != nil {
 		ctx.SendStatus(500)
 		return ctx.JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
+	err = client.ResolveIds(&listFilesRequest.ParentID)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if listFilesRequest.Recursive {
+		return listTree(ctx, client, &listFilesRequest)
+	}
+	if listFilesRequest.PageSize > 0 || listFilesRequest.PageToken != "" || listFilesRequest.OrderBy != "" || len(listFilesRequest.Fields) != 0 {
+		page, err := client.ListFilesPage(listFilesRequest.ParentID, listFilesRequest.Name, listFilesRequest.PageSize,
+			listFilesRequest.PageToken, listFilesRequest.OrderBy, listFilesRequest.Fields)
+		if err != nil {
+			ctx.SendStatus(400)
+			return ctx.JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.JSON(page)
+	}
 	files, err := client.ListFilesByParentId(listFilesRequest.ParentID, listFilesRequest.Name, listFilesRequest.Count)
 	if err != nil {
 		ctx.SendStatus(404)
@@ -39,3 +74,144 @@ func ListFilesHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) err
 	}
 	return ctx.JSON(rtr)
 }
+
+// listTree answers a recursive listing. json output is collected into a
+// tree or a flat list, jsonl and csv are streamed while the tree is walked.
+func listTree(ctx *fiber.Ctx, client *gdrive.GoogleDriveClient, request *types.ListFilesRequest) error {
+	output := request.Output
+	if output == "" {
+		output = listOutputTree
+	}
+	format := request.Format
+	if format == "" {
+		format = listFormatJson
+	}
+	if output != listOutputTree && output != listOutputFlat {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": fmt.Sprintf("unknown output: %s", output),
+		})
+	}
+	fields, err := gdrive.FileFields(request.Fields)
+	if err != nil {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	switch format {
+	case listFormatJson:
+		var entries []*gdrive.TreeEntry
+		err = client.ListTree(request.ParentID, request.Depth, fields, func(entry *gdrive.TreeEntry) error {
+			entries = append(entries, entry)
+			return nil
+		})
+		if err != nil {
+			ctx.SendStatus(404)
+			return ctx.JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		total := len(entries)
+		if output == listOutputTree {
+			entries = gdrive.BuildTree(request.ParentID, entries)
+		}
+		return ctx.JSON(fiber.Map{
+			"parent_id": request.ParentID,
+			"output":    output,
+			"total":     total,
+			"files":     entries,
+		})
+	case listFormatJsonl:
+		ctx.Set(fiber.HeaderContentType, "application/x-ndjson")
+	case listFormatCsv:
+		ctx.Set(fiber.HeaderContentType, "text/csv")
+	default:
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": fmt.Sprintf("unknown format: %s", format),
+		})
+	}
+	ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"list-%s.%s\"", request.ParentID, format))
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		var err error
+		if format == listFormatCsv {
+			err = streamTreeCsv(w, client, request, fields)
+		} else {
+			err = streamTreeJsonl(w, client, request, fields)
+		}
+		if err != nil {
+			logging.GetLogger().Error("Recursive listing failed", zap.Error(err), zap.String("parentId", request.ParentID))
+		}
+		w.Flush()
+	})
+	return nil
+}
+
+func streamTreeJsonl(w *bufio.Writer, client *gdrive.GoogleDriveClient, request *types.ListFilesRequest, fields []string) error {
+	encoder := json.NewEncoder(w)
+	err := client.ListTree(request.ParentID, request.Depth, fields, func(entry *gdrive.TreeEntry) error {
+		return encoder.Encode(entry)
+	})
+	if err != nil {
+		// the status line is long gone, report the failure in band
+		encoder.Encode(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return err
+}
+
+// streamTreeCsv writes one row per entry with the path, the depth and one
+// column per top level field.
+func streamTreeCsv(w *bufio.Writer, client *gdrive.GoogleDriveClient, request *types.ListFilesRequest, fields []string) error {
+	var columns []string
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		column := strings.SplitN(field, "/", 2)[0]
+		if !seen[column] {
+			seen[column] = true
+			columns = append(columns, column)
+		}
+	}
+	writer := csv.NewWriter(w)
+	err := writer.Write(append([]string{"path", "depth"}, columns...))
+	if err != nil {
+		return err
+	}
+	err = client.ListTree(request.ParentID, request.Depth, fields, func(entry *gdrive.TreeEntry) error {
+		raw, err := entry.File.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		values := make(map[string]json.RawMessage)
+		err = json.Unmarshal(raw, &values)
+		if err != nil {
+			return err
+		}
+		row := []string{entry.Path, fmt.Sprint(entry.Depth)}
+		for _, column := range columns {
+			row = append(row, csvValue(values[column]))
+		}
+		err = writer.Write(row)
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+	writer.Flush()
+	return err
+}
+
+// csvValue unquotes JSON strings and keeps anything else as JSON.
+func csvValue(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var value string
+	if json.Unmarshal(raw, &value) == nil {
+		return value
+	}
+	return string(raw)
+}
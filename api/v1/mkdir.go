@@ -0,0 +1,50 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/jaskaranSM/transfer-service/manager"
+	"github.com/jaskaranSM/transfer-service/types"
+)
+
+func MkdirHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
+	var mkdirRequest types.MkdirRequest
+	err := ctx.BodyParser(&mkdirRequest)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if mkdirRequest.ParentId == "" {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": "provide parent_id",
+		})
+	}
+	client, err := gdmanager.Client()
+	if err != nil {
+		ctx.SendStatus(500)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	err = client.ResolveIds(&mkdirRequest.ParentId)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	dir, created, err := client.MkdirAll(mkdirRequest.ParentId, mkdirRequest.Path)
+	if err != nil {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error":   err.Error(),
+			"created": created,
+		})
+	}
+	return ctx.JSON(fiber.Map{
+		"id":      dir.Id,
+		"name":    dir.Name,
+		"created": created,
+	})
+}
@@ -0,0 +1,105 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/jaskaranSM/transfer-service/manager"
+	"github.com/jaskaranSM/transfer-service/service/gdrive"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+	"github.com/jaskaranSM/transfer-service/types"
+)
+
+func RenameHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
+	var renameRequest types.RenameRequest
+	err := ctx.BodyParser(&renameRequest)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	client, err := gdmanager.Client()
+	if err != nil {
+		ctx.SendStatus(500)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	err = client.ResolveIds(&renameRequest.FileId)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	file, err := client.RenameFile(renameRequest.FileId, renameRequest.Name)
+	if err != nil {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.JSON(fiber.Map{
+		"file": file,
+	})
+}
+
+// MoveHandler moves the items given by id and matched by query into des_id.
+// Small batches are moved before responding, larger ones become a job whose
+// gid is returned.
+func MoveHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
+	var moveRequest types.MoveRequest
+	err := ctx.BodyParser(&moveRequest)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if moveRequest.DesId == "" || (len(moveRequest.FileIds) == 0 && moveRequest.Query == "") {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": "provide des_id and file_ids or query",
+		})
+	}
+	if moveRequest.Concurrency <= 0 {
+		moveRequest.Concurrency = 1
+	}
+	client := gdrive.NewGoogleDriveClient(moveRequest.Concurrency, 0, nil)
+	err = client.Authorize()
+	if err != nil {
+		ctx.SendStatus(500)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	err = client.ResolveIds(&moveRequest.DesId)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	files, err := client.ResolveFiles(moveRequest.FileIds, moveRequest.Query, moveRequest.IncludeTrashed)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if len(files) > gdriveconstants.BatchJobThreshold {
+		gid, err := gdmanager.AddMove(&manager.AddMoveOpts{
+			Files:       files,
+			DesId:       moveRequest.DesId,
+			Concurrency: moveRequest.Concurrency,
+		})
+		if err != nil {
+			return ctx.JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.JSON(fiber.Map{
+			"gid": gid,
+		})
+	}
+	return ctx.JSON(fiber.Map{
+		"results": client.MoveFiles(files, moveRequest.DesId),
+	})
+}
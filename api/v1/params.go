@@ -0,0 +1,27 @@
+package v1
+
+import (
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fileIdParam reads the fileId route param, Drive paths arrive with their
+// slashes escaped. An empty id means the error response was already sent
+// and err is what the handler returns.
+func fileIdParam(ctx *fiber.Ctx) (string, error) {
+	fileId, err := url.PathUnescape(ctx.Params("fileId"))
+	if err != nil {
+		ctx.SendStatus(400)
+		return "", ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if fileId == "" {
+		ctx.SendStatus(401)
+		return "", ctx.JSON(fiber.Map{
+			"error": "provide fileId in param, bad request",
+		})
+	}
+	return fileId, nil
+}
@@ -0,0 +1,142 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/jaskaranSM/transfer-service/manager"
+	"github.com/jaskaranSM/transfer-service/types"
+)
+
+func ListPermissionsHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
+	fileId, err := fileIdParam(ctx)
+	if fileId == "" {
+		return err
+	}
+	client, err := gdmanager.Client()
+	if err != nil {
+		ctx.SendStatus(500)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	err = client.ResolveIds(&fileId)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	permissions, err := client.ListPermissions(fileId)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.JSON(fiber.Map{
+		"file_id":     fileId,
+		"permissions": permissions,
+	})
+}
+
+func CreatePermissionHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
+	var createRequest types.CreatePermissionRequest
+	err := ctx.BodyParser(&createRequest)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	client, err := gdmanager.Client()
+	if err != nil {
+		ctx.SendStatus(500)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	err = client.ResolveIds(&createRequest.FileId)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	permission, err := client.CreatePermission(createRequest.FileId, &createRequest.PermissionOptions)
+	if err != nil {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.JSON(fiber.Map{
+		"permission": permission,
+	})
+}
+
+func UpdatePermissionHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
+	var updateRequest types.UpdatePermissionRequest
+	err := ctx.BodyParser(&updateRequest)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	client, err := gdmanager.Client()
+	if err != nil {
+		ctx.SendStatus(500)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	err = client.ResolveIds(&updateRequest.FileId)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	permission, err := client.UpdatePermission(updateRequest.FileId, updateRequest.PermissionId, updateRequest.Role)
+	if err != nil {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.JSON(fiber.Map{
+		"permission": permission,
+	})
+}
+
+func DeletePermissionHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
+	var deleteRequest types.DeletePermissionRequest
+	err := ctx.BodyParser(&deleteRequest)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	client, err := gdmanager.Client()
+	if err != nil {
+		ctx.SendStatus(500)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	err = client.ResolveIds(&deleteRequest.FileId)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	err = client.DeletePermission(deleteRequest.FileId, deleteRequest.PermissionId)
+	if err != nil {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.JSON(fiber.Map{
+		"file_id":       deleteRequest.FileId,
+		"permission_id": deleteRequest.PermissionId,
+	})
+}
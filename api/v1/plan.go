@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jaskaranSM/transfer-service/manager"
+)
+
+const planPageSize = 100
+const planMaxPageSize = 10000
+
+// PlanHandler pages through the plan of a dry run with offset and limit, or
+// sends the whole plan as a JSON Lines report with format=jsonl.
+func PlanHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
+	gid := ctx.Params("gid")
+	status := gdmanager.GetTransferStatusByGid(gid)
+	if status == nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": "gid not found in manager",
+		})
+	}
+	plan := status.Plan()
+	if plan == nil {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": "gid is not a dry run",
+		})
+	}
+	if ctx.Query("format") == "jsonl" {
+		entries := plan.Entries(0, 0)
+		ctx.Set(fiber.HeaderContentType, "application/x-ndjson")
+		ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"plan-%s.jsonl\"", gid))
+		ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			encoder := json.NewEncoder(w)
+			for _, entry := range entries {
+				if encoder.Encode(entry) != nil {
+					return
+				}
+			}
+			w.Flush()
+		})
+		return nil
+	}
+	offset, err := strconv.Atoi(ctx.Query("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	limit, err := strconv.Atoi(ctx.Query("limit", strconv.Itoa(planPageSize)))
+	if err != nil || limit <= 0 || limit > planMaxPageSize {
+		limit = planPageSize
+	}
+	return ctx.JSON(fiber.Map{
+		"gid":           gid,
+		"is_completed":  status.IsCompleted(),
+		"is_failed":     status.IsFailed(),
+		"summary":       plan.Summary(),
+		"total_entries": plan.Len(),
+		"offset":        offset,
+		"limit":         limit,
+		"entries":       plan.Entries(offset, limit),
+	})
+}
@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/jaskaranSM/transfer-service/manager"
+	"github.com/jaskaranSM/transfer-service/service/gdrive"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+	"github.com/jaskaranSM/transfer-service/types"
+)
+
+// RemoveHandler trashes, untrashes or permanently deletes file_ids, op picks
+// which. Permanent deletion needs confirm. Large batches and recursive
+// operations on folders become a job whose gid is returned.
+func RemoveHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager, op string) error {
+	var removeRequest types.RemoveRequest
+	err := ctx.BodyParser(&removeRequest)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if len(removeRequest.FileIds) == 0 {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": "provide file_ids",
+		})
+	}
+	if op == gdriveconstants.TransferTypeDeleting && !removeRequest.Confirm {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": "permanent deletion can not be undone, set confirm to true",
+		})
+	}
+	if removeRequest.Concurrency <= 0 {
+		removeRequest.Concurrency = 1
+	}
+	client := gdrive.NewGoogleDriveClient(removeRequest.Concurrency, 0, nil)
+	err = client.Authorize()
+	if err != nil {
+		ctx.SendStatus(500)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	files, err := client.ResolveFiles(removeRequest.FileIds, "", false)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	large := len(files) > gdriveconstants.BatchJobThreshold
+	for _, file := range files {
+		if removeRequest.Recursive && client.IsDir(file) {
+			large = true
+		}
+	}
+	if large {
+		gid, err := gdmanager.AddRemove(&manager.AddRemoveOpts{
+			Op:          op,
+			Files:       files,
+			Recursive:   removeRequest.Recursive,
+			Concurrency: removeRequest.Concurrency,
+		})
+		if err != nil {
+			return ctx.JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return ctx.JSON(fiber.Map{
+			"gid": gid,
+		})
+	}
+	return ctx.JSON(fiber.Map{
+		"results": client.RemoveFiles(op, files, removeRequest.Recursive),
+	})
+}
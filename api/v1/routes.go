@@ -3,6 +3,7 @@ package v1
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/jaskaranSM/transfer-service/manager"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
 )
 
 func AddRoutes(router fiber.Router) {
@@ -23,12 +24,24 @@ func AddRoutes(router fiber.Router) {
 			return StatusHandler(c, gdmanager)
 		},
 	)
+	router.Get(
+		"/plan/:gid",
+		func(c *fiber.Ctx) error {
+			return PlanHandler(c, gdmanager)
+		},
+	)
 	router.Get(
 		"/filemetadata/:fileId",
 		func(c *fiber.Ctx) error {
 			return FileMetdataHandler(c, gdmanager)
 		},
 	)
+	router.Get(
+		"/stats/:fileId",
+		func(c *fiber.Ctx) error {
+			return StatsHandler(c, gdmanager)
+		},
+	)
 	router.Post(
 		"/upload",
 		func(c *fiber.Ctx) error {
@@ -41,6 +54,108 @@ func AddRoutes(router fiber.Router) {
 			return DownloadHandler(c, gdmanager)
 		},
 	)
+	router.Post(
+		"/syncupload",
+		func(c *fiber.Ctx) error {
+			return SyncUploadHandler(c, gdmanager)
+		},
+	)
+	router.Post(
+		"/syncdownload",
+		func(c *fiber.Ctx) error {
+			return SyncDownloadHandler(c, gdmanager)
+		},
+	)
+	router.Post(
+		"/syncclone",
+		func(c *fiber.Ctx) error {
+			return SyncCloneHandler(c, gdmanager)
+		},
+	)
+	router.Post(
+		"/mkdir",
+		func(c *fiber.Ctx) error {
+			return MkdirHandler(c, gdmanager)
+		},
+	)
+	router.Post(
+		"/rename",
+		func(c *fiber.Ctx) error {
+			return RenameHandler(c, gdmanager)
+		},
+	)
+	router.Post(
+		"/move",
+		func(c *fiber.Ctx) error {
+			return MoveHandler(c, gdmanager)
+		},
+	)
+	router.Post(
+		"/trash",
+		func(c *fiber.Ctx) error {
+			return RemoveHandler(c, gdmanager, gdriveconstants.TransferTypeTrashing)
+		},
+	)
+	router.Post(
+		"/untrash",
+		func(c *fiber.Ctx) error {
+			return RemoveHandler(c, gdmanager, gdriveconstants.TransferTypeUntrashing)
+		},
+	)
+	router.Post(
+		"/delete",
+		func(c *fiber.Ctx) error {
+			return RemoveHandler(c, gdmanager, gdriveconstants.TransferTypeDeleting)
+		},
+	)
+	router.Get(
+		"/permissions/:fileId",
+		func(c *fiber.Ctx) error {
+			return ListPermissionsHandler(c, gdmanager)
+		},
+	)
+	router.Post(
+		"/permissions",
+		func(c *fiber.Ctx) error {
+			return CreatePermissionHandler(c, gdmanager)
+		},
+	)
+	router.Patch(
+		"/permissions",
+		func(c *fiber.Ctx) error {
+			return UpdatePermissionHandler(c, gdmanager)
+		},
+	)
+	router.Delete(
+		"/permissions",
+		func(c *fiber.Ctx) error {
+			return DeletePermissionHandler(c, gdmanager)
+		},
+	)
+	router.Get(
+		"/drives",
+		func(c *fiber.Ctx) error {
+			return ListDrivesHandler(c, gdmanager)
+		},
+	)
+	router.Post(
+		"/drives",
+		func(c *fiber.Ctx) error {
+			return CreateDriveHandler(c, gdmanager)
+		},
+	)
+	router.Post(
+		"/drives/members",
+		func(c *fiber.Ctx) error {
+			return DriveMembersHandler(c, gdmanager, false)
+		},
+	)
+	router.Delete(
+		"/drives/members",
+		func(c *fiber.Ctx) error {
+			return DriveMembersHandler(c, gdmanager, true)
+		},
+	)
 	router.Post(
 		"/cancel",
 		func(c *fiber.Ctx) error {
@@ -53,5 +168,11 @@ func AddRoutes(router fiber.Router) {
 			return ListFilesHandler(c, gdmanager)
 		},
 	)
+	router.Post(
+		"/search",
+		func(c *fiber.Ctx) error {
+			return SearchHandler(c, gdmanager)
+		},
+	)
 
 }
@@ -0,0 +1,42 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/jaskaranSM/transfer-service/manager"
+	"github.com/jaskaranSM/transfer-service/types"
+)
+
+// SearchHandler answers one page of a structured search, the next page is
+// requested with the returned next_page_token.
+func SearchHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
+	var searchRequest types.SearchRequest
+	err := ctx.BodyParser(&searchRequest)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	client, err := gdmanager.Client()
+	if err != nil {
+		ctx.SendStatus(500)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	err = client.ResolveIds(&searchRequest.ParentId, &searchRequest.DriveId)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	result, err := client.Search(&searchRequest)
+	if err != nil {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.JSON(result)
+}
@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jaskaranSM/transfer-service/manager"
+	"github.com/jaskaranSM/transfer-service/service/gdrive"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+)
+
+// StatsHandler counts a folder right away, folders with more subfolders
+// than StatsSyncMaxFolders are counted as a job and answered with its gid.
+// background=true always starts a job.
+func StatsHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
+	fileId, err := fileIdParam(ctx)
+	if fileId == "" {
+		return err
+	}
+	top, err := strconv.Atoi(ctx.Query("top", strconv.Itoa(gdriveconstants.StatsDefaultTop)))
+	if err != nil || top <= 0 || top > gdriveconstants.StatsMaxTop {
+		top = gdriveconstants.StatsDefaultTop
+	}
+
+	client, err := gdmanager.Client()
+	if err != nil {
+		ctx.SendStatus(500)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	err = client.ResolveIds(&fileId)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	file, err := client.GetFileMetadata(fileId)
+	if err != nil {
+		ctx.SendStatus(404)
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if !client.IsDir(file) {
+		ctx.SendStatus(400)
+		return ctx.JSON(fiber.Map{
+			"error": "fileId is not a folder",
+		})
+	}
+	if ctx.Query("background") != "true" {
+		stats, err := client.GetFolderStats(fileId, top, gdriveconstants.StatsSyncMaxFolders)
+		if err == nil {
+			return ctx.JSON(fiber.Map{
+				"file_id": fileId,
+				"name":    file.Name,
+				"stats":   stats,
+			})
+		}
+		if !errors.Is(err, gdrive.ErrFolderTooBig) {
+			ctx.SendStatus(500)
+			return ctx.JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+	gid, err := gdmanager.AddStats(&manager.AddStatsOpts{
+		FileId: fileId,
+		Top:    top,
+	})
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.JSON(fiber.Map{
+		"gid": gid,
+	})
+}
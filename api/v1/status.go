@@ -32,9 +32,30 @@ func StatusHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error
 		"transfer_type":    status.GetTransferType(),
 		"name":             status.Name(),
 		"file_id":          status.GetFileID(),
+		"completed_files":  status.CompletedFiles(),
+		"skipped_files":    status.SkippedFiles(),
+		"deleted_files":    status.DeletedFiles(),
+		"shortcuts":        status.Shortcuts(),
 	}
 	if err != nil {
 		rtr["error"] = err.Error()
 	}
+	if link := status.WebViewLink(); link != "" {
+		rtr["web_view_link"] = link
+	}
+	if shareErr := status.GetShareError(); shareErr != nil {
+		rtr["share_error"] = shareErr.Error()
+	}
+	if plan := status.Plan(); plan != nil {
+		rtr["dry_run"] = true
+		rtr["plan"] = plan.Summary()
+	}
+	if stats := status.FolderStats(); stats != nil {
+		rtr["stats"] = stats
+	}
+	failedFiles := status.FailedFiles()
+	if len(failedFiles) != 0 {
+		rtr["failed_files"] = failedFiles
+	}
 	return ctx.JSON(rtr)
 }
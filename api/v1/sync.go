@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/jaskaranSM/transfer-service/manager"
+	"github.com/jaskaranSM/transfer-service/types"
+)
+
+func SyncUploadHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
+	var syncRequest types.SyncUploadRequest
+	err := ctx.BodyParser(&syncRequest)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	gid, err := gdmanager.AddSyncUpload(&manager.AddSyncUploadOpts{
+		Path:        syncRequest.Path,
+		ParentId:    syncRequest.ParentId,
+		Concurrency: syncRequest.Concurrency,
+		Size:        syncRequest.Size,
+		Verify:      syncRequest.Verify,
+		Filter:      syncRequest.Filter,
+		DryRun:      syncRequest.DryRun,
+		CompareMd5:  syncRequest.CompareMd5,
+		Delete:      syncRequest.Delete,
+		Share:       syncRequest.Share,
+	})
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.JSON(fiber.Map{
+		"gid": gid,
+	})
+}
+
+func SyncDownloadHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
+	var syncRequest types.SyncDownloadRequest
+	err := ctx.BodyParser(&syncRequest)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	gid, err := gdmanager.AddSyncDownload(&manager.AddSyncDownloadOpts{
+		FileId:      syncRequest.FileId,
+		LocalDir:    syncRequest.LocalDir,
+		Concurrency: syncRequest.Concurrency,
+		Size:        syncRequest.Size,
+		Verify:      syncRequest.Verify,
+		Filter:      syncRequest.Filter,
+		DryRun:      syncRequest.DryRun,
+		CompareMd5:  syncRequest.CompareMd5,
+		Delete:      syncRequest.Delete,
+		Shortcuts:   syncRequest.Shortcuts,
+		Export:      syncRequest.ExportOptions,
+	})
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.JSON(fiber.Map{
+		"gid": gid,
+	})
+}
+
+func SyncCloneHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error {
+	var syncRequest types.SyncCloneRequest
+	err := ctx.BodyParser(&syncRequest)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	gid, err := gdmanager.AddSyncClone(&manager.AddSyncCloneOpts{
+		FileId:      syncRequest.FileId,
+		DesId:       syncRequest.DesId,
+		Concurrency: syncRequest.Concurrency,
+		Size:        syncRequest.Size,
+		Verify:      syncRequest.Verify,
+		Filter:      syncRequest.Filter,
+		DryRun:      syncRequest.DryRun,
+		Delete:      syncRequest.Delete,
+		Shortcuts:   syncRequest.Shortcuts,
+		Share:       syncRequest.Share,
+	})
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return ctx.JSON(fiber.Map{
+		"gid": gid,
+	})
+}
@@ -19,6 +19,13 @@ func UploadHandler(ctx *fiber.Ctx, gdmanager *manager.GoogleDriveManager) error
 		ParentId:    uploadRequest.ParentId,
 		Concurrency: uploadRequest.Concurrency,
 		Size:        uploadRequest.Size,
+		Verify:      uploadRequest.Verify,
+		Filter:      uploadRequest.Filter,
+		DryRun:      uploadRequest.DryRun,
+		Share:       uploadRequest.Share,
+		Conflict:    uploadRequest.Conflict,
+		Incremental: uploadRequest.Incremental,
+		CompareMd5:  uploadRequest.CompareMd5,
 	})
 	if err != nil {
 		return ctx.JSON(fiber.Map{
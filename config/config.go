@@ -17,7 +17,24 @@ type Config struct {
 	LogLevel string `mapstructure:"LOG_LEVEL"`
 
 	// Gdrive config
-	UseSA bool `mapstructure:"USE_SA"`
+	UseSA            bool  `mapstructure:"USE_SA"`
+	SegmentThreshold int64 `mapstructure:"SEGMENT_THRESHOLD"`
+	SegmentSize      int64 `mapstructure:"SEGMENT_SIZE"`
+	WalkConcurrency  int   `mapstructure:"WALK_CONCURRENCY"`
+
+	// Export config
+	ExportFormats string `mapstructure:"EXPORT_FORMATS"`
+	ExportSkip    string `mapstructure:"EXPORT_SKIP"`
+
+	// Path config
+	PathAliases  string `mapstructure:"PATH_ALIASES"`
+	PathCacheTTL int    `mapstructure:"PATH_CACHE_TTL"`
+
+	// Metadata cache config
+	MetadataCacheTTL int `mapstructure:"METADATA_CACHE_TTL"`
+
+	// State config
+	StateDir string `mapstructure:"STATE_DIR"`
 }
 
 var cfg *Config
@@ -31,6 +48,15 @@ func init() {
 	viper.SetDefault("APP_PORT", 6969)
 	viper.SetDefault("LOG_LEVEL", "")
 	viper.SetDefault("USE_SA", true)
+	viper.SetDefault("STATE_DIR", "state")
+	viper.SetDefault("SEGMENT_THRESHOLD", 1024*1024*1024)
+	viper.SetDefault("SEGMENT_SIZE", 64*1024*1024)
+	viper.SetDefault("WALK_CONCURRENCY", 8)
+	viper.SetDefault("EXPORT_FORMATS", "document=docx,spreadsheet=xlsx,presentation=pptx,drawing=png,script=json")
+	viper.SetDefault("EXPORT_SKIP", "form,site,map,fusiontable,jam")
+	viper.SetDefault("PATH_ALIASES", "")
+	viper.SetDefault("PATH_CACHE_TTL", 300)
+	viper.SetDefault("METADATA_CACHE_TTL", 300)
 	viper.SetDefault("ENVIRONMENT", "")
 	viper.AutomaticEnv()
 
@@ -3,13 +3,16 @@ package manager
 import (
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
+	"google.golang.org/api/drive/v3"
 
 	"github.com/jaskaranSM/transfer-service/logging"
 	"github.com/jaskaranSM/transfer-service/service/gdrive"
 	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+	"github.com/jaskaranSM/transfer-service/types"
 	"github.com/jaskaranSM/transfer-service/utils"
 )
 
@@ -35,6 +38,9 @@ type GoogleDriveTransferStatus struct {
 	transferType                   string
 	fileID                         string
 	err                            error
+	share                          []types.PermissionOptions
+	webViewLink                    string
+	shareErr                       error
 	onTransferCompleteUserCallback func()
 }
 
@@ -42,6 +48,33 @@ func (g *GoogleDriveTransferStatus) SetClient(client *gdrive.GoogleDriveClient)
 	g.client = client
 }
 
+// SetShare checks the share block of a submit request, its permissions are
+// granted on the result once the transfer completed.
+func (g *GoogleDriveTransferStatus) SetShare(share []types.PermissionOptions) error {
+	for i := range share {
+		err := gdrive.CheckPermission(&share[i])
+		if err != nil {
+			return err
+		}
+	}
+	g.share = share
+	return nil
+}
+
+func (g *GoogleDriveTransferStatus) applyShare(client *gdrive.GoogleDriveClient, fileId string) {
+	if len(g.share) == 0 {
+		return
+	}
+	logger := logging.GetLogger()
+	link, err := client.Share(fileId, g.share)
+	if err != nil {
+		logger.Error("Could not share transfer result", zap.String("fileID", fileId), zap.Error(err))
+		g.shareErr = err
+		return
+	}
+	g.webViewLink = link
+}
+
 func (g *GoogleDriveTransferStatus) cleanup() error {
 	if g.cleanAfterComplete {
 		return os.RemoveAll(g.path)
@@ -72,6 +105,7 @@ func (g *GoogleDriveTransferStatus) SpeedObserver() {
 func (g *GoogleDriveTransferStatus) OnTransferComplete(client *gdrive.GoogleDriveClient, fileId string) {
 	logger := logging.GetLogger()
 	g.fileID = fileId
+	g.applyShare(client, fileId)
 	g.isCompleted = true
 	g.StopSpeedObserver()
 	logger.Debug(fmt.Sprintf("on %s complete: ", g.transferType), zap.String("fileID", fileId))
@@ -100,6 +134,14 @@ func (g *GoogleDriveTransferStatus) GetFileID() string {
 	return g.fileID
 }
 
+func (g *GoogleDriveTransferStatus) WebViewLink() string {
+	return g.webViewLink
+}
+
+func (g *GoogleDriveTransferStatus) GetShareError() error {
+	return g.shareErr
+}
+
 func (g *GoogleDriveTransferStatus) CompletedLength() int64 {
 	return g.client.CompletedLength()
 }
@@ -128,6 +170,36 @@ func (g *GoogleDriveTransferStatus) Name() string {
 	return g.client.Name
 }
 
+func (g *GoogleDriveTransferStatus) CompletedFiles() int {
+	return g.client.CompletedFiles()
+}
+
+func (g *GoogleDriveTransferStatus) SkippedFiles() int {
+	return g.client.SkippedFiles()
+}
+
+func (g *GoogleDriveTransferStatus) DeletedFiles() int {
+	return g.client.DeletedFiles()
+}
+
+func (g *GoogleDriveTransferStatus) Shortcuts() gdrive.ShortcutCounters {
+	return g.client.Shortcuts()
+}
+
+// Plan returns the plan of a dry run, nil for a real transfer.
+func (g *GoogleDriveTransferStatus) Plan() *gdrive.Plan {
+	return g.client.Plan()
+}
+
+// FolderStats returns the counts of a stats job, nil for any other transfer.
+func (g *GoogleDriveTransferStatus) FolderStats() *gdrive.FolderStats {
+	return g.client.FolderStats()
+}
+
+func (g *GoogleDriveTransferStatus) FailedFiles() []*gdrive.FailedFile {
+	return g.client.FailedFiles()
+}
+
 func (g *GoogleDriveTransferStatus) Cancel() {
 	g.client.Cancel()
 }
@@ -139,6 +211,13 @@ type AddUploadOpts struct {
 	CleanAfterComplete       bool
 	Size                     int64
 	Concurrency              int
+	Verify                   bool
+	Filter                   types.FilterOptions
+	DryRun                   bool
+	Incremental              bool
+	CompareMd5               bool
+	Conflict                 types.ConflictOptions
+	Share                    []types.PermissionOptions
 	OnUploadCompleteCallback func()
 }
 
@@ -148,6 +227,12 @@ type AddDownloadOpts struct {
 	Gid                        string
 	Size                       int64
 	Concurrency                int
+	Verify                     bool
+	Filter                     types.FilterOptions
+	DryRun                     bool
+	Conflict                   types.ConflictOptions
+	Export                     types.ExportOptions
+	Shortcuts                  string
 	OnDownloadCompleteCallback func()
 }
 
@@ -157,9 +242,79 @@ type AddCloneOpts struct {
 	Gid                     string
 	Size                    int64
 	Concurrency             int
+	Verify                  bool
+	Filter                  types.FilterOptions
+	DryRun                  bool
+	Conflict                types.ConflictOptions
+	Shortcuts               string
+	Share                   []types.PermissionOptions
 	OnCloneCompleteCallback func()
 }
 
+type AddSyncUploadOpts struct {
+	Path        string
+	ParentId    string
+	Gid         string
+	Size        int64
+	Concurrency int
+	Verify      bool
+	Filter      types.FilterOptions
+	DryRun      bool
+	CompareMd5  bool
+	Delete      bool
+	Share       []types.PermissionOptions
+}
+
+type AddSyncDownloadOpts struct {
+	FileId      string
+	LocalDir    string
+	Gid         string
+	Size        int64
+	Concurrency int
+	Verify      bool
+	Filter      types.FilterOptions
+	DryRun      bool
+	CompareMd5  bool
+	Delete      bool
+	Shortcuts   string
+	Export      types.ExportOptions
+}
+
+type AddSyncCloneOpts struct {
+	FileId      string
+	DesId       string
+	Gid         string
+	Size        int64
+	Concurrency int
+	Verify      bool
+	Filter      types.FilterOptions
+	DryRun      bool
+	Delete      bool
+	Shortcuts   string
+	Share       []types.PermissionOptions
+}
+
+type AddMoveOpts struct {
+	Files       []*drive.File
+	DesId       string
+	Gid         string
+	Concurrency int
+}
+
+type AddRemoveOpts struct {
+	Op          string
+	Files       []*drive.File
+	Recursive   bool
+	Gid         string
+	Concurrency int
+}
+
+type AddStatsOpts struct {
+	FileId string
+	Top    int
+	Gid    string
+}
+
 func NewGoogleDriveManager() *GoogleDriveManager {
 	return &GoogleDriveManager{
 		queue: make(map[string]*GoogleDriveTransferStatus),
@@ -167,25 +322,77 @@ func NewGoogleDriveManager() *GoogleDriveManager {
 }
 
 type GoogleDriveManager struct {
-	queue map[string]*GoogleDriveTransferStatus
+	queue     map[string]*GoogleDriveTransferStatus
+	clientMut sync.Mutex
+	client    *gdrive.GoogleDriveClient
 }
 
 func (g *GoogleDriveManager) GetTransferStatusByGid(gid string) *GoogleDriveTransferStatus {
 	return g.queue[gid]
 }
 
+// Client returns the authorized client shared by requests which are answered
+// right away, it is created on first use.
+func (g *GoogleDriveManager) Client() (*gdrive.GoogleDriveClient, error) {
+	g.clientMut.Lock()
+	defer g.clientMut.Unlock()
+	if g.client != nil {
+		return g.client, nil
+	}
+	client := gdrive.NewGoogleDriveClient(1, 0, nil)
+	err := client.Authorize()
+	if err != nil {
+		return nil, err
+	}
+	g.client = client
+	return client, nil
+}
+
+// resolveIds turns Drive paths of a submit request into ids before a job is
+// queued for it.
+func (g *GoogleDriveManager) resolveIds(refs ...*string) error {
+	client, err := g.Client()
+	if err != nil {
+		return err
+	}
+	return client.ResolveIds(refs...)
+}
+
 func (g *GoogleDriveManager) AddDownload(opts *AddDownloadOpts) (string, error) {
 	if opts.Gid == "" {
 		opts.Gid = utils.RandString(16)
 	}
+	filter, err := utils.NewFilter(opts.Filter)
+	if err != nil {
+		return opts.Gid, err
+	}
 
+	err = g.resolveIds(&opts.FileId)
+	if err != nil {
+		return opts.Gid, err
+	}
 	status := NewGoogleDriveTransferStatus(opts.Gid, gdriveconstants.TransferTypeDownloading, opts.FileId, false, func() {
 	})
 
 	client := gdrive.NewGoogleDriveClient(opts.Concurrency, opts.Size, status)
+	client.SetFilter(filter)
+	client.SetDryRun(opts.DryRun)
+	client.SetVerify(opts.Verify)
+	err = client.SetConflictPolicy(opts.Conflict.Folders, opts.Conflict.Files)
+	if err != nil {
+		return opts.Gid, err
+	}
+	err = client.SetExportOptions(opts.Export.Export, opts.Export.ExportSkip, opts.Export.AcknowledgeAbuse)
+	if err != nil {
+		return opts.Gid, err
+	}
+	err = client.SetShortcutPolicy(opts.Shortcuts)
+	if err != nil {
+		return opts.Gid, err
+	}
 	status.SetClient(client)
 	g.queue[status.gid] = status
-	err := client.Authorize()
+	err = client.Authorize()
 	if err != nil {
 		return opts.Gid, err
 	}
@@ -204,12 +411,37 @@ func (g *GoogleDriveManager) AddClone(opts *AddCloneOpts) (string, error) {
 	if opts.Gid == "" {
 		opts.Gid = utils.RandString(16)
 	}
+	filter, err := utils.NewFilter(opts.Filter)
+	if err != nil {
+		return opts.Gid, err
+	}
+	err = g.resolveIds(&opts.FileId, &opts.DesId)
+	if err != nil {
+		return opts.Gid, err
+	}
 	status := NewGoogleDriveTransferStatus(opts.Gid, gdriveconstants.TransferTypeCloning, opts.FileId, false, func() {
 	})
 	client := gdrive.NewGoogleDriveClient(opts.Concurrency, opts.Size, status)
+	client.SetFilter(filter)
+	client.SetDryRun(opts.DryRun)
+	client.SetVerify(opts.Verify)
+	err = client.SetConflictPolicy(opts.Conflict.Folders, opts.Conflict.Files)
+	if err != nil {
+		return opts.Gid, err
+	}
+	err = client.SetShortcutPolicy(opts.Shortcuts)
+	if err != nil {
+		return opts.Gid, err
+	}
+	if !opts.DryRun {
+		err = status.SetShare(opts.Share)
+		if err != nil {
+			return opts.Gid, err
+		}
+	}
 	status.SetClient(client)
 	g.queue[status.gid] = status
-	err := client.Authorize()
+	err = client.Authorize()
 	if err != nil {
 		return opts.Gid, err
 	}
@@ -227,19 +459,41 @@ func (g *GoogleDriveManager) AddUpload(opts *AddUploadOpts) (string, error) {
 	if opts.Gid == "" {
 		opts.Gid = utils.RandString(16)
 	}
-	status := NewGoogleDriveTransferStatus(opts.Gid, gdriveconstants.TransferTypeUploading, opts.Path, opts.CleanAfterComplete, func() {
+	filter, err := utils.NewFilter(opts.Filter)
+	if err != nil {
+		return opts.Gid, err
+	}
+	err = g.resolveIds(&opts.ParentId)
+	if err != nil {
+		return opts.Gid, err
+	}
+	status := NewGoogleDriveTransferStatus(opts.Gid, gdriveconstants.TransferTypeUploading, opts.Path, opts.CleanAfterComplete && !opts.DryRun, func() {
 	})
 	if opts.Size == 0 {
-		size, err := utils.GetPathSize(opts.Path)
+		size, err := utils.GetPathSize(opts.Path, filter)
 		if err != nil {
 			logger.Error("Could not get path size", zap.Error(err))
 		}
 		opts.Size = size
 	}
 	client := gdrive.NewGoogleDriveClient(opts.Concurrency, opts.Size, status)
+	client.SetFilter(filter)
+	client.SetDryRun(opts.DryRun)
+	client.SetVerify(opts.Verify)
+	err = client.SetConflictPolicy(opts.Conflict.Folders, opts.Conflict.Files)
+	if err != nil {
+		return opts.Gid, err
+	}
+	client.SetIncremental(opts.Incremental, opts.CompareMd5)
+	if !opts.DryRun {
+		err = status.SetShare(opts.Share)
+		if err != nil {
+			return opts.Gid, err
+		}
+	}
 	status.SetClient(client)
 	g.queue[status.gid] = status
-	err := client.Authorize()
+	err = client.Authorize()
 	if err != nil {
 		return opts.Gid, err
 	}
@@ -251,3 +505,219 @@ func (g *GoogleDriveManager) AddUpload(opts *AddUploadOpts) (string, error) {
 	}()
 	return opts.Gid, nil
 }
+
+func (g *GoogleDriveManager) AddSyncUpload(opts *AddSyncUploadOpts) (string, error) {
+	logger := logging.GetLogger()
+	if opts.Gid == "" {
+		opts.Gid = utils.RandString(16)
+	}
+	filter, err := utils.NewFilter(opts.Filter)
+	if err != nil {
+		return opts.Gid, err
+	}
+	err = g.resolveIds(&opts.ParentId)
+	if err != nil {
+		return opts.Gid, err
+	}
+	status := NewGoogleDriveTransferStatus(opts.Gid, gdriveconstants.TransferTypeSyncUploading, opts.Path, false, func() {
+	})
+	if opts.Size == 0 {
+		size, err := utils.GetPathSize(opts.Path, filter)
+		if err != nil {
+			logger.Error("Could not get path size", zap.Error(err))
+		}
+		opts.Size = size
+	}
+	client := gdrive.NewGoogleDriveClient(opts.Concurrency, opts.Size, status)
+	client.SetFilter(filter)
+	client.SetDryRun(opts.DryRun)
+	client.SetVerify(opts.Verify)
+	client.SetIncremental(true, opts.CompareMd5)
+	client.SetMirror(opts.Delete)
+	if !opts.DryRun {
+		err = status.SetShare(opts.Share)
+		if err != nil {
+			return opts.Gid, err
+		}
+	}
+	status.SetClient(client)
+	g.queue[status.gid] = status
+	err = client.Authorize()
+	if err != nil {
+		return opts.Gid, err
+	}
+	go func() {
+		err := client.SyncUpload(opts.Path, opts.ParentId)
+		if err != nil {
+			logger.Error("Error while syncing upload", zap.Error(err))
+		}
+	}()
+	return opts.Gid, nil
+}
+
+func (g *GoogleDriveManager) AddSyncDownload(opts *AddSyncDownloadOpts) (string, error) {
+	logger := logging.GetLogger()
+	if opts.Gid == "" {
+		opts.Gid = utils.RandString(16)
+	}
+	filter, err := utils.NewFilter(opts.Filter)
+	if err != nil {
+		return opts.Gid, err
+	}
+	err = g.resolveIds(&opts.FileId)
+	if err != nil {
+		return opts.Gid, err
+	}
+	status := NewGoogleDriveTransferStatus(opts.Gid, gdriveconstants.TransferTypeSyncDownloading, opts.FileId, false, func() {
+	})
+	client := gdrive.NewGoogleDriveClient(opts.Concurrency, opts.Size, status)
+	client.SetFilter(filter)
+	client.SetDryRun(opts.DryRun)
+	client.SetVerify(opts.Verify)
+	client.SetIncremental(true, opts.CompareMd5)
+	client.SetMirror(opts.Delete)
+	err = client.SetExportOptions(opts.Export.Export, opts.Export.ExportSkip, opts.Export.AcknowledgeAbuse)
+	if err != nil {
+		return opts.Gid, err
+	}
+	err = client.SetShortcutPolicy(opts.Shortcuts)
+	if err != nil {
+		return opts.Gid, err
+	}
+	status.SetClient(client)
+	g.queue[status.gid] = status
+	err = client.Authorize()
+	if err != nil {
+		return opts.Gid, err
+	}
+	go func() {
+		err := client.SyncDownload(opts.FileId, opts.LocalDir)
+		if err != nil {
+			logger.Error("Error while syncing download", zap.Error(err))
+		}
+	}()
+	return opts.Gid, nil
+}
+
+func (g *GoogleDriveManager) AddSyncClone(opts *AddSyncCloneOpts) (string, error) {
+	logger := logging.GetLogger()
+	if opts.Gid == "" {
+		opts.Gid = utils.RandString(16)
+	}
+	filter, err := utils.NewFilter(opts.Filter)
+	if err != nil {
+		return opts.Gid, err
+	}
+	err = g.resolveIds(&opts.FileId, &opts.DesId)
+	if err != nil {
+		return opts.Gid, err
+	}
+	status := NewGoogleDriveTransferStatus(opts.Gid, gdriveconstants.TransferTypeSyncCloning, opts.FileId, false, func() {
+	})
+	client := gdrive.NewGoogleDriveClient(opts.Concurrency, opts.Size, status)
+	client.SetFilter(filter)
+	client.SetDryRun(opts.DryRun)
+	client.SetVerify(opts.Verify)
+	client.SetIncremental(true, false)
+	client.SetMirror(opts.Delete)
+	err = client.SetShortcutPolicy(opts.Shortcuts)
+	if err != nil {
+		return opts.Gid, err
+	}
+	if !opts.DryRun {
+		err = status.SetShare(opts.Share)
+		if err != nil {
+			return opts.Gid, err
+		}
+	}
+	status.SetClient(client)
+	g.queue[status.gid] = status
+	err = client.Authorize()
+	if err != nil {
+		return opts.Gid, err
+	}
+	go func() {
+		err := client.SyncClone(opts.FileId, opts.DesId)
+		if err != nil {
+			logger.Error("Error while syncing clone", zap.Error(err))
+		}
+	}()
+	return opts.Gid, nil
+}
+
+// AddMove runs a batch move as a tracked job, the items are resolved by the
+// caller.
+func (g *GoogleDriveManager) AddMove(opts *AddMoveOpts) (string, error) {
+	logger := logging.GetLogger()
+	if opts.Gid == "" {
+		opts.Gid = utils.RandString(16)
+	}
+	var size int64
+	for _, file := range opts.Files {
+		size += file.Size
+	}
+	status := NewGoogleDriveTransferStatus(opts.Gid, gdriveconstants.TransferTypeMoving, opts.DesId, false, func() {
+	})
+	client := gdrive.NewGoogleDriveClient(opts.Concurrency, size, status)
+	status.SetClient(client)
+	g.queue[status.gid] = status
+	err := client.Authorize()
+	if err != nil {
+		return opts.Gid, err
+	}
+	go func() {
+		err := client.Move(opts.Files, opts.DesId)
+		if err != nil {
+			logger.Error("Error while moving files", zap.Error(err))
+		}
+	}()
+	return opts.Gid, nil
+}
+
+// AddRemove runs a batch trash, untrash or permanent delete as a tracked job.
+func (g *GoogleDriveManager) AddRemove(opts *AddRemoveOpts) (string, error) {
+	logger := logging.GetLogger()
+	if opts.Gid == "" {
+		opts.Gid = utils.RandString(16)
+	}
+	status := NewGoogleDriveTransferStatus(opts.Gid, opts.Op, "", false, func() {
+	})
+	client := gdrive.NewGoogleDriveClient(opts.Concurrency, 0, status)
+	status.SetClient(client)
+	g.queue[status.gid] = status
+	err := client.Authorize()
+	if err != nil {
+		return opts.Gid, err
+	}
+	go func() {
+		err := client.Remove(opts.Op, opts.Files, opts.Recursive)
+		if err != nil {
+			logger.Error("Error while removing files", zap.String("op", opts.Op), zap.Error(err))
+		}
+	}()
+	return opts.Gid, nil
+}
+
+// AddStats counts a folder as a tracked job.
+func (g *GoogleDriveManager) AddStats(opts *AddStatsOpts) (string, error) {
+	logger := logging.GetLogger()
+	if opts.Gid == "" {
+		opts.Gid = utils.RandString(16)
+	}
+	status := NewGoogleDriveTransferStatus(opts.Gid, gdriveconstants.TransferTypeStats, opts.FileId, false, func() {
+	})
+	client := gdrive.NewGoogleDriveClient(1, 0, status)
+	status.SetClient(client)
+	g.queue[status.gid] = status
+	err := client.Authorize()
+	if err != nil {
+		return opts.Gid, err
+	}
+	go func() {
+		err := client.Stats(opts.FileId, opts.Top)
+		if err != nil {
+			logger.Error("Error while counting folder", zap.String("fileId", opts.FileId), zap.Error(err))
+		}
+	}()
+	return opts.Gid, nil
+}
@@ -5,14 +5,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 
 	"github.com/jaskaranSM/transfer-service/config"
@@ -33,16 +37,44 @@ type GoogleDriveClient struct {
 	isCancelled          bool
 	completedFiles       int
 	callbackFired        bool
+	localClaims          localClaims
 	fileId               string
 	wg                   sync.WaitGroup
 	DriveSrv             *drive.Service
 	SaFiles              []string
 	Name                 string
+	verify               bool
+	failedFiles          []*FailedFile
+	incremental          bool
+	compareMd5           bool
+	skippedFiles         int
+	compareModTime       bool
+	deleteExtraneous     bool
+	deletedFiles         int
+	folderConflict       string
+	fileConflict         string
+	exportFormats        map[string]string
+	exportSkip           map[string]bool
+	acknowledgeAbuse     bool
+	shortcutPolicy       string
+	shortcuts            ShortcutCounters
+	filter               *utils.Filter
+	plan                 *Plan
+	stats                *statsCollector
+}
+
+type FailedFile struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
 }
 
 func (gd *GoogleDriveClient) init() {
 	logger := logging.GetLogger()
 	cfg := config.Get()
+	err := gd.SetExportOptions(nil, nil, false)
+	if err != nil {
+		logger.Error("Invalid export config", zap.Error(err))
+	}
 	if cfg.UseSA {
 		files, err := os.ReadDir(gdriveconstants.SADir)
 		if err != nil {
@@ -55,18 +87,29 @@ func (gd *GoogleDriveClient) init() {
 	}
 }
 
-func (gd *GoogleDriveClient) GetDriveService() (srv *drive.Service, err error) {
+func (gd *GoogleDriveClient) GetHTTPClient() (client *http.Client, err error) {
 	cfg := config.Get()
 	logger := logging.GetLogger()
 
-	client, err := gd.getAuthorizedHTTPClient(cfg.UseSA)
+	client, err = gd.getAuthorizedHTTPClient(cfg.UseSA)
 	if err != nil {
 		logger.Error("Could not get authorized HTTP client", zap.Error(err),
 			zap.Bool("UseSA", cfg.UseSA),
 		)
+	}
+	return
+}
+
+func (gd *GoogleDriveClient) GetDriveService() (srv *drive.Service, err error) {
+	client, err := gd.GetHTTPClient()
+	if err != nil {
 		return
 	}
+	return gd.newDriveService(client)
+}
 
+func (gd *GoogleDriveClient) newDriveService(client *http.Client) (srv *drive.Service, err error) {
+	logger := logging.GetLogger()
 	srv, err = drive.NewService(context.Background(), option.WithHTTPClient(client))
 	if err != nil {
 		logger.Error("Could not create new google drive service", zap.Error(err))
@@ -154,12 +197,20 @@ func (gd *GoogleDriveClient) saveToken(path string, token *oauth2.Token) {
 func (gd *GoogleDriveClient) CreateDir(name string, parentId string) (*drive.File, error) {
 	logger := logging.GetLogger()
 	logger.Debug("CreateDir: ", zap.String("name", name), zap.String("parentId", parentId))
+	if gd.plan != nil {
+		return &drive.File{
+			Id:       gd.plan.addFolder(gdriveconstants.PlanCreateFolder, "", parentId, name),
+			Name:     name,
+			MimeType: "application/vnd.google-apps.folder",
+		}, nil
+	}
 	d := &drive.File{
 		Name:     name,
 		MimeType: "application/vnd.google-apps.folder",
 		Parents:  []string{parentId},
 	}
 	file, err := gd.DriveSrv.Files.Create(d).SupportsAllDrives(true).Do()
+	invalidateMetadata(parentId)
 	if err != nil {
 		logger.Error("Could not create dir", zap.Error(err),
 			zap.String("file path", name),
@@ -171,27 +222,75 @@ func (gd *GoogleDriveClient) CreateDir(name string, parentId string) (*drive.Fil
 	return file, nil
 }
 
-func (gd *GoogleDriveClient) OnTransferError(_ *GoogleDriveFileTransfer, err error) {
+func (gd *GoogleDriveClient) SetVerify(verify bool) {
+	gd.verify = verify
+}
+
+// SetIncremental makes uploads reuse existing folders and skip files whose
+// size, and md5 when compareMd5 is set, already match in the destination.
+func (gd *GoogleDriveClient) SetIncremental(incremental bool, compareMd5 bool) {
+	gd.incremental = incremental
+	gd.compareMd5 = compareMd5
+}
+
+func (gd *GoogleDriveClient) CompletedFiles() int {
+	gd.mut.Lock()
+	defer gd.mut.Unlock()
+	return gd.completedFiles
+}
+
+func (gd *GoogleDriveClient) SkippedFiles() int {
+	gd.mut.Lock()
+	defer gd.mut.Unlock()
+	return gd.skippedFiles
+}
+
+func (gd *GoogleDriveClient) FailedFiles() []*FailedFile {
+	gd.mut.Lock()
+	defer gd.mut.Unlock()
+	return gd.failedFiles
+}
+
+func (gd *GoogleDriveClient) OnTransferError(transfer *GoogleDriveFileTransfer, err error) {
 	logger := logging.GetLogger()
 	logger.Error("Error on Transfer", zap.Error(err))
+	gd.mut.Lock()
+	gd.failedFiles = append(gd.failedFiles, &FailedFile{
+		Name:  transfer.Name(),
+		Error: err.Error(),
+	})
+	gd.mut.Unlock()
 
 	<-gd.concurrency
 	gd.wg.Done()
-	if gd.callbackFired {
+	if !gd.claimCallback() {
 		logger.Debug("Already callback fired")
 		return
 	}
-	gd.callbackFired = true
 	gd.listener.OnTransferError(gd, err)
 }
 
+// claimCallback reports whether the caller is the first to report the
+// failure of the job.
+func (gd *GoogleDriveClient) claimCallback() bool {
+	gd.mut.Lock()
+	defer gd.mut.Unlock()
+	if gd.callbackFired {
+		return false
+	}
+	gd.callbackFired = true
+	return true
+}
+
 func (gd *GoogleDriveClient) OnTransferStart(transfer *GoogleDriveFileTransfer) {
 	logger := logging.GetLogger()
 	logger.Debug("Starting Transfer")
 }
 
 func (gd *GoogleDriveClient) OnTransferComplete(transfer *GoogleDriveFileTransfer) {
+	gd.mut.Lock()
 	gd.completedFiles += 1
+	gd.mut.Unlock()
 	logger := logging.GetLogger()
 	gd.fileId = transfer.fileId
 	logger.Debug("Transfer Completed",
@@ -209,6 +308,10 @@ func (gd *GoogleDriveClient) OnTransferUpdate(transfer *GoogleDriveFileTransfer,
 	defer gd.mut.Unlock()
 
 	gd.completed += chunk
+	if transfer.exportMime != "" {
+		// exports have no size up front, grow the total as they stream
+		gd.total += chunk
+	}
 	logger.Debug("Transfer Updated",
 		zap.Int64("file chunk", chunk),
 		zap.Int64("Total completed", gd.completed),
@@ -222,14 +325,79 @@ func (gd *GoogleDriveClient) OnTransferTemporaryError(_ *GoogleDriveFileTransfer
 }
 
 // ListFilesByParentId count = -1 for disabling limit
+// Complete listings are served from the metadata cache.
 func (gd *GoogleDriveClient) ListFilesByParentId(parentId string, name string, count int) ([]*drive.File, error) {
+	full := name == "" && count == -1
+	if full {
+		if files, ok := metadata.getChildren(parentId); ok {
+			return files, nil
+		}
+	}
+	query := fmt.Sprintf("'%s' in parents and trashed = false", escapeQuery(parentId))
+	if name != "" {
+		query += fmt.Sprintf(" and name contains '%s'", escapeQuery(name))
+	}
+	files, err := gd.listFiles(query, count)
+	if err == nil && full {
+		metadata.putChildren(parentId, files)
+	}
+	return files, err
+}
+
+type FilePage struct {
+	Files         []*drive.File `json:"files"`
+	NextPageToken string        `json:"next_page_token,omitempty"`
+}
+
+// ListFilesPage lists one page of the children of parentId, pageToken
+// continues a previous page. Pages skip the metadata cache.
+func (gd *GoogleDriveClient) ListFilesPage(parentId string, name string, pageSize int, pageToken string, orderBy string, fields []string) (*FilePage, error) {
 	logger := logging.GetLogger()
-	var files []*drive.File
-	pageToken := ""
-	query := fmt.Sprintf("'%s' in parents", parentId)
+	fields, err := FileFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	if orderBy == "" {
+		orderBy = "modifiedTime desc"
+	}
+	orderBy, err = ParseOrderBy(orderBy)
+	if err != nil {
+		return nil, err
+	}
+	if pageSize <= 0 {
+		pageSize = gdriveconstants.ListPageSize
+	}
+	if pageSize > gdriveconstants.ListMaxPageSize {
+		pageSize = gdriveconstants.ListMaxPageSize
+	}
+	query := fmt.Sprintf("'%s' in parents and trashed = false", escapeQuery(parentId))
 	if name != "" {
-		query += fmt.Sprintf(" and name contains '%s'", name)
+		query += fmt.Sprintf(" and name contains '%s'", escapeQuery(name))
+	}
+	request := gd.DriveSrv.Files.List().Q(query).OrderBy(orderBy).SupportsAllDrives(true).IncludeItemsFromAllDrives(true).
+		PageSize(int64(pageSize)).Fields(googleapi.Field(fmt.Sprintf("nextPageToken,files(%s)", strings.Join(fields, ","))))
+	if pageToken != "" {
+		request = request.PageToken(pageToken)
 	}
+	res, err := request.Do()
+	if err != nil {
+		logger.Error("Could not list page", zap.Error(err), zap.String("parentId", parentId))
+		return nil, err
+	}
+	return &FilePage{
+		Files:         res.Files,
+		NextPageToken: res.NextPageToken,
+	}, nil
+}
+
+func (gd *GoogleDriveClient) listFiles(query string, count int) ([]*drive.File, error) {
+	return gd.listFilesWithFields(query, count, "nextPageToken,files(id, name, size, mimeType, md5Checksum, modifiedTime, shortcutDetails)")
+}
+
+func (gd *GoogleDriveClient) listFilesWithFields(query string, count int, fields string) ([]*drive.File, error) {
+	logger := logging.GetLogger()
+	var files []*drive.File
+	pageToken := ""
 	for {
 		logger.Debug("Listing files in folder",
 			zap.String("query", query),
@@ -237,13 +405,14 @@ func (gd *GoogleDriveClient) ListFilesByParentId(parentId string, name string, c
 		)
 
 		request := gd.DriveSrv.Files.List().Q(query).OrderBy("modifiedTime desc").SupportsAllDrives(true).IncludeTeamDriveItems(true).PageSize(1000).
-			Fields("nextPageToken,files(id, name, size, mimeType)")
+			Fields(googleapi.Field(fields))
 
 		if pageToken != "" {
 			request = request.PageToken(pageToken)
 		}
 
 		res, err := request.Do()
+		gd.plan.countCall()
 		if err != nil {
 			logger.Error("Error while doing a request",
 				zap.Error(err),
@@ -267,181 +436,445 @@ func (gd *GoogleDriveClient) ListFilesByParentId(parentId string, name string, c
 	return files, nil
 }
 
+func (gd *GoogleDriveClient) newFileTransfer(cb func(*drive.File)) (*GoogleDriveFileTransfer, error) {
+	client, err := gd.GetHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	service, err := gd.newDriveService(client)
+	if err != nil {
+		return nil, err
+	}
+	transfer := NewGoogleDriveFileTransfer(service, client, gd, cb)
+	transfer.verify = gd.verify
+	transfer.acknowledgeAbuse = gd.acknowledgeAbuse
+	return transfer, nil
+}
+
 func (gd *GoogleDriveClient) HandleCloneFile(file *drive.File, desId string, cb func(*drive.File)) error {
-	service, err := gd.GetDriveService()
+	return gd.handleCloneAs(file, "", desId, cb)
+}
+
+// handleCloneAs copies file under name, an empty name keeps the source name.
+func (gd *GoogleDriveClient) handleCloneAs(file *drive.File, name string, desId string, cb func(*drive.File)) error {
+	if gd.plan != nil {
+		action := gdriveconstants.PlanCopy
+		if name != "" {
+			action = gdriveconstants.PlanRename
+		}
+		gd.planClone(action, file, name, desId, "")
+		return nil
+	}
+	transfer, err := gd.newFileTransfer(cb)
 	if err != nil {
 		return err
 	}
-	transfer := NewGoogleDriveFileTransfer(service, gd, cb)
+	transfer.targetName = name
 	gd.concurrency <- 1
 	gd.wg.Add(1)
 	go transfer.Clone(file, desId, 0)
-	gd.currentTransferQueue = append(gd.currentTransferQueue, transfer)
+	gd.addTransfer(transfer)
 	return nil
 }
 
 func (gd *GoogleDriveClient) CloneDir(dir *drive.File, parentId string) error {
+	root := &walkItem{
+		src:   dir.Id,
+		des:   parentId,
+		chain: []string{dir.Id},
+	}
+	return gd.walkTree(root, gd.cloneFolder)
+}
+
+// cloneFolder copies the entries of the source folders of one destination
+// folder and returns its subfolders once they exist at the destination.
+func (gd *GoogleDriveClient) cloneFolder(dirItem *walkItem) ([]*walkItem, error) {
 	logger := logging.GetLogger()
-	q := utils.NewQueue()
-	dirValue := utils.NewDirValue(dir.Id, parentId)
-	q.Enqueue(dirValue)
-	for !q.IsEmpty() {
+	var entries *destEntries
+	var entriesErr error
+	var listing sync.WaitGroup
+	if gd.needsDestEntries() {
+		listing.Add(1)
+		go func() {
+			defer listing.Done()
+			entries, entriesErr = gd.listDestEntries(dirItem.des)
+		}()
+	}
+	files, err := gd.listSources(dirItem)
+	listing.Wait()
+	if err != nil {
+		logger.Error("Error while listing gdrive directory contents", zap.Error(err), zap.String("src", dirItem.src))
+		return nil, err
+	}
+	if entriesErr != nil {
+		logger.Error("Could not list destination folder", zap.Error(entriesErr), zap.String("des", dirItem.des))
+		return nil, entriesErr
+	}
+
+	var children []*walkItem
+	kept := make(map[string]bool)
+	for _, file := range files {
 		if gd.isCancelled {
-			return errors.New("cancelled by user")
-		}
-		dirItem := q.Deque()
-		files, err := gd.ListFilesByParentId(dirItem.Src, "", -1)
-		if err != nil {
-			logger.Error("Error while listing gdrive directory contents", zap.Error(err), zap.String("src", dirItem.Src))
-			return err
+			return nil, errors.New("cancelled by user")
 		}
-
-		for _, file := range files {
-			if gd.isCancelled {
-				return errors.New("cancelled by user")
+		if IsShortcut(file) {
+			file = gd.handleShortcut(file, dirItem.chain, true)
+			if file == nil {
+				continue
 			}
-			if file.MimeType == "application/vnd.google-apps.folder" {
-				newDir, err := gd.CreateDir(file.Name, dirItem.Des)
-				if err != nil {
-					return err
-				}
-				q.Enqueue(utils.NewDirValue(file.Id, newDir.Id))
-			} else {
-				logger.Info(file.Id)
-				err := gd.HandleCloneFile(file, dirItem.Des, func(f *drive.File) {})
-				if err != nil {
-					return err
+		}
+		relPath := joinRel(dirItem.rel, file.Name)
+		if !gd.matchDriveFile(file, relPath) {
+			logger.Debug("Skipping filtered entry", zap.String("path", relPath))
+			if gd.incremental {
+				// filtered entries are left alone at the destination
+				if existing := entries.Folder(file.Name); gd.IsDir(file) && existing != nil {
+					kept[existing.Id] = true
+				} else if existing := entries.File(file.Name); !gd.IsDir(file) && existing != nil {
+					kept[existing.Id] = true
 				}
 			}
+			continue
+		}
+		if file.MimeType == "application/vnd.google-apps.folder" {
+			newDir, err := gd.createDirIn(file.Name, dirItem.des, entries)
+			if err != nil {
+				return nil, err
+			}
+			kept[newDir.Id] = true
+			children = addChild(children, dirItem, file.Id, newDir.Id, file.Name)
+		} else if gd.incremental {
+			existing := entries.File(file.Name)
+			if existing != nil {
+				kept[existing.Id] = true
+			}
+			err := gd.handleIncrementalClone(file, dirItem.des, existing)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			logger.Info(file.Id)
+			err := gd.handleCloneConflict(file, dirItem.des, entries, func(f *drive.File) {})
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
-	return nil
+	if gd.incremental {
+		err = gd.trashExtraneous(dirItem.des, entries, kept)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return children, nil
 }
 
 func (gd *GoogleDriveClient) DownloadDir(dir *drive.File, localDir string) error {
+	root := &walkItem{
+		src:   dir.Id,
+		des:   localDir,
+		chain: []string{dir.Id},
+	}
+	return gd.walkTree(root, gd.downloadFolder)
+}
+
+// downloadName is the local name of file in a download, skip is set for
+// Google native files which are not exported.
+func (gd *GoogleDriveClient) downloadName(file *drive.File) (string, bool) {
+	if gd.IsDir(file) {
+		return file.Name, false
+	}
+	if _, skip := gd.exportFor(file); skip {
+		return "", true
+	}
+	return gd.localFileName(file), false
+}
+
+// downloadFolder downloads the entries of the source folders of one local
+// directory and returns its subfolders once they exist locally.
+func (gd *GoogleDriveClient) downloadFolder(dirItem *walkItem) ([]*walkItem, error) {
 	logger := logging.GetLogger()
-	q := utils.NewQueue()
-	dirValue := utils.NewDirValue(dir.Id, localDir)
-	q.Enqueue(dirValue)
-	for !q.IsEmpty() {
+	files, err := gd.listSources(dirItem)
+	if err != nil {
+		return nil, err
+	}
+	var children []*walkItem
+	kept := make(map[string]bool)
+	for _, file := range files {
 		if gd.isCancelled {
-			return errors.New("cancelled by user")
+			return nil, errors.New("cancelled by user")
 		}
-		dirItem := q.Deque()
-		files, err := gd.ListFilesByParentId(dirItem.Src, "", -1)
-		if err != nil {
-			return err
+		if IsShortcut(file) {
+			file = gd.handleShortcut(file, dirItem.chain, false)
+			if file == nil {
+				continue
+			}
+		}
+		name, skip := gd.downloadName(file)
+		if skip {
+			logger.Debug("Skipping google native file without export", zap.String("name", file.Name), zap.String("mimeType", file.MimeType))
+			gd.skipFile(filepath.Join(dirItem.des, file.Name), 0)
+			continue
 		}
-		for _, file := range files {
-			if gd.isCancelled {
-				return errors.New("cancelled by user")
+		absPath := filepath.Join(dirItem.des, name)
+		kept[name] = true
+		relPath := joinRel(dirItem.rel, name)
+		if !gd.matchDriveFile(file, relPath) {
+			logger.Debug("Skipping filtered entry", zap.String("path", relPath))
+			continue
+		}
+		if file.MimeType == "application/vnd.google-apps.folder" {
+			err = gd.mkdirLocal(absPath)
+			if err != nil {
+				logger.Error("Error while creating directories ", zap.Error(err),
+					zap.String("Absolute Path", absPath),
+				)
+				return nil, err
 			}
-			absPath := filepath.Join(dirItem.Des, file.Name)
-			if file.MimeType == "application/vnd.google-apps.folder" {
-				err = os.MkdirAll(absPath, 0755)
-				if err != nil {
-					logger.Error("Error while creating directories ", zap.Error(err),
-						zap.String("Absolute Path", absPath),
-					)
-					return err
-				}
-				v := utils.NewDirValue(file.Id, absPath)
-				q.Enqueue(v)
-			} else {
-				err = gd.HandleDownloadFile(file, dirItem.Des)
-				if err != nil {
-					return err
-				}
+			children = addChild(children, dirItem, file.Id, absPath, name)
+		} else if gd.incremental && gd.isDownloaded(absPath, file) {
+			logger.Debug("Skipping already downloaded file", zap.String("path", absPath))
+			gd.skipFile(absPath, file.Size)
+		} else {
+			err = gd.handleDownloadConflict(file, dirItem.des)
+			if err != nil {
+				return nil, err
 			}
 		}
 	}
-	return nil
+	if gd.incremental {
+		err = gd.removeExtraneousLocal(dirItem.des, kept)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return children, nil
 }
 
 func (gd *GoogleDriveClient) UploadDir(dir string, parentId string) error {
+	root := &walkItem{
+		src: dir,
+		des: parentId,
+	}
+	return gd.walkTree(root, gd.uploadFolder)
+}
+
+// uploadFolder uploads the entries of one local directory and returns its
+// subdirectories once their folders exist on Drive.
+func (gd *GoogleDriveClient) uploadFolder(dirItem *walkItem) ([]*walkItem, error) {
 	logger := logging.GetLogger()
-	q := utils.NewQueue()
-	dirValue := utils.NewDirValue(dir, parentId)
-	q.Enqueue(dirValue)
-	for !q.IsEmpty() {
+	files, err := os.ReadDir(dirItem.src)
+	if err != nil {
+		logger.Error("Could not Read directory", zap.Error(err),
+			zap.String("source directory", dirItem.src),
+		)
+		return nil, err
+	}
+	var entries *destEntries
+	if gd.needsDestEntries() {
+		entries, err = gd.listDestEntries(dirItem.des)
+		if err != nil {
+			logger.Error("Could not list destination folder", zap.Error(err), zap.String("des", dirItem.des))
+			return nil, err
+		}
+	}
+	var children []*walkItem
+	kept := make(map[string]bool)
+	for _, file := range files {
 		if gd.isCancelled {
-			return errors.New("cancelled by user")
+			return nil, errors.New("cancelled by user")
 		}
-		dirItem := q.Deque()
-		files, err := os.ReadDir(dirItem.Src)
+		absPath := filepath.Join(dirItem.src, file.Name())
+		matched, err := gd.matchLocalFile(joinRel(dirItem.rel, file.Name()), file)
 		if err != nil {
-			logger.Error("Could not Read directory", zap.Error(err),
-				zap.String("source directory", dirItem.Src),
-			)
-			return err
+			return nil, err
 		}
-		for _, file := range files {
-			if gd.isCancelled {
-				return errors.New("cancelled by user")
-			}
-			absPath := filepath.Join(dirItem.Src, file.Name())
-			if file.IsDir() {
-				var dirV *drive.File
-				basePath := filepath.Base(file.Name())
-				dirV, err = gd.CreateDir(basePath, dirItem.Des)
-				if err != nil {
-					return err
-				}
-				v := utils.NewDirValue(absPath, dirV.Id)
-				q.Enqueue(v)
-			} else {
-				err = gd.HandleUploadFile(absPath, dirItem.Des, func(f *drive.File) {})
-				if err != nil {
-					return err
+		if !matched {
+			logger.Debug("Skipping filtered entry", zap.String("path", absPath))
+			if gd.incremental {
+				// filtered entries are left alone at the destination
+				if existing := entries.Folder(file.Name()); file.IsDir() && existing != nil {
+					kept[existing.Id] = true
+				} else if existing := entries.File(file.Name()); !file.IsDir() && existing != nil {
+					kept[existing.Id] = true
 				}
 			}
+			continue
+		}
+		if file.IsDir() {
+			var dirV *drive.File
+			basePath := filepath.Base(file.Name())
+			dirV, err = gd.createDirIn(basePath, dirItem.des, entries)
+			if err != nil {
+				return nil, err
+			}
+			kept[dirV.Id] = true
+			children = append(children, dirItem.child(absPath, dirV.Id, file.Name()))
+		} else if gd.incremental {
+			info, err := file.Info()
+			if err != nil {
+				return nil, err
+			}
+			if existing := entries.File(info.Name()); existing != nil {
+				kept[existing.Id] = true
+			}
+			err = gd.handleIncrementalUpload(absPath, info, dirItem.des, entries, func(f *drive.File) {})
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			info, err := file.Info()
+			if err != nil {
+				return nil, err
+			}
+			err = gd.handleUploadConflict(absPath, info, dirItem.des, entries, func(f *drive.File) {})
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
-	return nil
+	if gd.incremental {
+		err = gd.trashExtraneous(dirItem.des, entries, kept)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return children, nil
 }
 
 func (gd *GoogleDriveClient) HandleDownloadFile(file *drive.File, localDir string) error {
-	service, err := gd.GetDriveService()
+	return gd.handleDownloadTo(file, path.Join(localDir, gd.localFileName(file)))
+}
+
+func (gd *GoogleDriveClient) handleDownloadTo(file *drive.File, localPath string) error {
+	if gd.plan != nil {
+		gd.planDownload(file, localPath)
+		return nil
+	}
+	transfer, err := gd.newFileTransfer(nil)
 	if err != nil {
 		return err
 	}
-	transfer := NewGoogleDriveFileTransfer(service, gd, nil)
+	if ext, _ := gd.exportFor(file); ext != "" {
+		transfer.exportMime = exportMimeTypes[ext]
+	}
 	gd.concurrency <- 1
 	gd.wg.Add(1)
-	go transfer.Download(file, path.Join(localDir, file.Name), 0)
-	gd.currentTransferQueue = append(gd.currentTransferQueue, transfer)
+	if shouldSegment(file, cap(gd.concurrency)) {
+		go transfer.SegmentedDownload(file, localPath, gd.concurrency, 0)
+	} else {
+		go transfer.Download(file, localPath, 0)
+	}
+	gd.addTransfer(transfer)
 	return nil
 }
 
 func (gd *GoogleDriveClient) HandleUploadFile(path string, parentId string, cb func(*drive.File)) error {
-	service, err := gd.GetDriveService()
+	return gd.handleUploadAs(path, "", parentId, cb)
+}
+
+// handleUploadAs uploads the file at path under name, an empty name keeps
+// the local file name.
+func (gd *GoogleDriveClient) handleUploadAs(path string, name string, parentId string, cb func(*drive.File)) error {
+	if gd.plan != nil {
+		action := gdriveconstants.PlanCopy
+		if name != "" {
+			action = gdriveconstants.PlanRename
+		}
+		return gd.planUpload(action, path, name, parentId, "")
+	}
+	transfer, err := gd.newFileTransfer(cb)
 	if err != nil {
 		return err
 	}
-	transfer := NewGoogleDriveFileTransfer(service, gd, cb)
+	transfer.targetName = name
 	gd.concurrency <- 1
 	gd.wg.Add(1)
 	go transfer.Upload(path, parentId, 0)
-	gd.currentTransferQueue = append(gd.currentTransferQueue, transfer)
+	gd.addTransfer(transfer)
 	return nil
 }
 
+// HandleUpdateFile uploads the file at path as a new revision of fileId.
+func (gd *GoogleDriveClient) HandleUpdateFile(path string, fileId string, parentId string, cb func(*drive.File)) error {
+	if gd.plan != nil {
+		return gd.planUpload(gdriveconstants.PlanOverwrite, path, "", parentId, fileId)
+	}
+	transfer, err := gd.newFileTransfer(cb)
+	if err != nil {
+		return err
+	}
+	transfer.updateFileId = fileId
+	gd.concurrency <- 1
+	gd.wg.Add(1)
+	go transfer.Upload(path, parentId, 0)
+	gd.addTransfer(transfer)
+	return nil
+}
+
+// addTransfer records transfer so Cancel and the completion checks see it,
+// walkers add transfers from several goroutines.
+func (gd *GoogleDriveClient) addTransfer(transfer *GoogleDriveFileTransfer) {
+	gd.mut.Lock()
+	defer gd.mut.Unlock()
+	gd.currentTransferQueue = append(gd.currentTransferQueue, transfer)
+}
+
+func (gd *GoogleDriveClient) transfers() []*GoogleDriveFileTransfer {
+	gd.mut.Lock()
+	defer gd.mut.Unlock()
+	return append([]*GoogleDriveFileTransfer(nil), gd.currentTransferQueue...)
+}
+
+// failWalk cancels the transfers a failed walk already started and waits
+// for them before the job is reported as failed with the walk error. The
+// callback is claimed first so the cancelled transfers do not report.
+func (gd *GoogleDriveClient) failWalk(err error) {
+	fire := gd.claimCallback()
+	gd.Cancel()
+	gd.wg.Wait()
+	if fire {
+		gd.listener.OnTransferError(gd, err)
+	}
+}
+
 func (gd *GoogleDriveClient) Cancel() {
 	gd.isCancelled = true
-	for _, tr := range gd.currentTransferQueue {
+	for _, tr := range gd.transfers() {
 		tr.Cancel()
 	}
 }
 
+// GetFileFields gets the selected fields of fileId, it skips the metadata
+// cache.
+func (gd *GoogleDriveClient) GetFileFields(fileId string, fields []string) (*drive.File, error) {
+	fields, err := FileFields(fields)
+	if err != nil {
+		return nil, err
+	}
+	file, err := gd.DriveSrv.Files.Get(fileId).Fields(googleapi.Field(strings.Join(fields, ","))).SupportsAllDrives(true).Do()
+	if err != nil {
+		logging.GetLogger().Error("Could not get object from file ID", zap.Error(err),
+			zap.String("file ID", fileId),
+		)
+		return nil, err
+	}
+	return file, nil
+}
+
 func (gd *GoogleDriveClient) GetFileMetadata(fileId string) (*drive.File, error) {
 	logger := logging.GetLogger()
-	file, err := gd.DriveSrv.Files.Get(fileId).Fields("name,mimeType,size,id,md5Checksum").SupportsAllDrives(true).Do()
+	if file, ok := metadata.getFile(fileId); ok {
+		return file, nil
+	}
+	file, err := gd.DriveSrv.Files.Get(fileId).Fields("name,mimeType,size,id,md5Checksum,modifiedTime,shortcutDetails").SupportsAllDrives(true).Do()
 	if err != nil {
 		logger.Error("Could not get object from file ID", zap.Error(err),
 			zap.String("file ID", fileId),
 		)
 		return nil, fmt.Errorf("GetFileMetadata: %v", err)
 	}
+	metadata.putFile(file)
 	return file, nil
 }
 
@@ -454,10 +887,25 @@ func (gd *GoogleDriveClient) Clone(srcId string, desId string) error {
 		gd.listener.OnTransferError(gd, err)
 		return err
 	}
+	if IsShortcut(meta) && gd.shortcutPolicy == gdriveconstants.ShortcutFollow {
+		meta, err = gd.resolveShortcut(meta, nil)
+		if err != nil {
+			gd.listener.OnTransferError(gd, err)
+			return err
+		}
+	}
 	gd.Name = meta.Name
 	var fileId string
+	var entries *destEntries
+	if gd.needsDestEntries() {
+		entries, err = gd.listDestEntries(desId)
+		if err != nil {
+			gd.listener.OnTransferError(gd, err)
+			return err
+		}
+	}
 	if meta.MimeType == "application/vnd.google-apps.folder" {
-		newDir, err := gd.CreateDir(meta.Name, desId)
+		newDir, err := gd.createDirIn(meta.Name, desId, entries)
 		if err != nil {
 			gd.listener.OnTransferError(gd, err)
 			return err
@@ -465,16 +913,20 @@ func (gd *GoogleDriveClient) Clone(srcId string, desId string) error {
 		fileId = newDir.Id
 		err = gd.CloneDir(meta, newDir.Id)
 		if err != nil {
-			gd.listener.OnTransferError(gd, err)
+			gd.failWalk(err)
 			return err
 		}
 	} else {
-		err = gd.HandleCloneFile(meta, desId, func(f *drive.File) {
+		err = gd.handleCloneConflict(meta, desId, entries, func(f *drive.File) {
 			fileId = f.Id
 		})
+		if err != nil {
+			gd.listener.OnTransferError(gd, err)
+			return err
+		}
 	}
 	gd.wg.Wait()
-	for _, tr := range gd.currentTransferQueue {
+	for _, tr := range gd.transfers() {
 		if tr.isCompleted == false {
 			return tr.err
 		}
@@ -487,18 +939,60 @@ func (G *GoogleDriveClient) IsDir(file *drive.File) bool {
 	return file.MimeType == "application/vnd.google-apps.folder"
 }
 
-func (gd *GoogleDriveClient) GetFolderSize(folderId string, size *int64) {
-	files, _ := gd.ListFilesByParentId(folderId, "", -1)
+// GetFolderSize sums the sizes of the files below folderId. Google native
+// files report no size, the total grows while their exports stream. With
+// local set filters see the names files get in a download.
+func (gd *GoogleDriveClient) GetFolderSize(folderId string, size *int64, local bool) error {
+	root := &walkItem{
+		src:   folderId,
+		chain: []string{folderId},
+	}
+	return gd.walkTree(root, func(dirItem *walkItem) ([]*walkItem, error) {
+		return gd.sizeFolder(dirItem, size, local)
+	})
+}
+
+// sizeFolder adds the file sizes of one folder to size and returns its
+// subfolders.
+func (gd *GoogleDriveClient) sizeFolder(dirItem *walkItem, size *int64, local bool) ([]*walkItem, error) {
+	var children []*walkItem
+	files, err := gd.ListFilesByParentId(dirItem.src, "", -1)
+	if err != nil {
+		logging.GetLogger().Error("Could not list folder for its size", zap.Error(err), zap.String("folderId", dirItem.src))
+		return nil, err
+	}
 	for _, file := range files {
 		if gd.isCancelled {
-			return
+			return nil, errors.New("cancelled by user")
+		}
+		if IsShortcut(file) {
+			if gd.shortcutPolicy != gdriveconstants.ShortcutFollow {
+				continue
+			}
+			target, err := gd.resolveShortcut(file, dirItem.chain)
+			if err != nil {
+				continue
+			}
+			file = target
+		}
+		name := file.Name
+		if local {
+			var skip bool
+			name, skip = gd.downloadName(file)
+			if skip {
+				continue
+			}
+		}
+		if !gd.matchDriveFile(file, joinRel(dirItem.rel, name)) {
+			continue
 		}
 		if file.MimeType == "application/vnd.google-apps.folder" {
-			gd.GetFolderSize(file.Id, size)
+			children = append(children, dirItem.child(file.Id, "", name))
 		} else {
-			*size += file.Size
+			atomic.AddInt64(size, file.Size)
 		}
 	}
+	return children, nil
 }
 
 func (gd *GoogleDriveClient) Download(fileId string, localDir string) error {
@@ -509,11 +1003,27 @@ func (gd *GoogleDriveClient) Download(fileId string, localDir string) error {
 		gd.listener.OnTransferError(gd, err)
 		return nil
 	}
+	if IsShortcut(meta) {
+		if gd.shortcutPolicy != gdriveconstants.ShortcutFollow {
+			err = fmt.Errorf("%s is a shortcut, set the shortcut policy to follow to download its target", meta.Name)
+			gd.listener.OnTransferError(gd, err)
+			return nil
+		}
+		meta, err = gd.resolveShortcut(meta, nil)
+		if err != nil {
+			gd.listener.OnTransferError(gd, err)
+			return nil
+		}
+	}
 	gd.Name = meta.Name
 	if gd.total == 0 {
 		gd.Name = "getting metadata"
 		if gd.IsDir(meta) {
-			gd.GetFolderSize(meta.Id, &gd.total)
+			err = gd.GetFolderSize(meta.Id, &gd.total, true)
+			if err != nil {
+				gd.listener.OnTransferError(gd, err)
+				return nil
+			}
 		} else {
 			gd.total = meta.Size
 		}
@@ -522,7 +1032,7 @@ func (gd *GoogleDriveClient) Download(fileId string, localDir string) error {
 	var outPath string
 	if meta.MimeType == "application/vnd.google-apps.folder" {
 		outPath = filepath.Join(localDir, meta.Name)
-		err = os.MkdirAll(outPath, 0755)
+		err = gd.mkdirLocal(outPath)
 		if err != nil {
 			logger.Error("Could create directories", zap.Error(err),
 				zap.String("file path", outPath),
@@ -532,18 +1042,23 @@ func (gd *GoogleDriveClient) Download(fileId string, localDir string) error {
 		}
 		err = gd.DownloadDir(meta, outPath)
 		if err != nil {
-			gd.listener.OnTransferError(gd, err)
+			gd.failWalk(err)
 			return nil
 		}
 	} else {
-		err = gd.HandleDownloadFile(meta, localDir)
+		if _, skip := gd.exportFor(meta); skip {
+			err = fmt.Errorf("%s can not be downloaded or exported", meta.MimeType)
+			gd.listener.OnTransferError(gd, err)
+			return nil
+		}
+		err = gd.handleDownloadConflict(meta, localDir)
 		if err != nil {
 			gd.listener.OnTransferError(gd, err)
 			return nil
 		}
 	}
 	gd.wg.Wait()
-	for _, tr := range gd.currentTransferQueue {
+	for _, tr := range gd.transfers() {
 		if tr.isCompleted == false {
 			return tr.err
 		}
@@ -565,21 +1080,37 @@ func (gd *GoogleDriveClient) Upload(path string, parentId string) error {
 		return err
 	}
 	var fileId string
+	var entries *destEntries
+	if gd.needsDestEntries() {
+		entries, err = gd.listDestEntries(parentId)
+		if err != nil {
+			gd.listener.OnTransferError(gd, err)
+			return err
+		}
+	}
 	if stat.IsDir() {
 		var dir *drive.File
-		dir, err = gd.CreateDir(filepath.Base(path), parentId)
+		dir, err = gd.createDirIn(filepath.Base(path), parentId, entries)
 		if err != nil {
 			gd.listener.OnTransferError(gd, err)
 			return err
 		}
 		fileId = dir.Id
 		err = gd.UploadDir(path, dir.Id)
+		if err != nil {
+			gd.failWalk(err)
+			return nil
+		}
+	} else if gd.incremental {
+		err = gd.handleIncrementalUpload(path, stat, parentId, entries, func(f *drive.File) {
+			fileId = f.Id
+		})
 		if err != nil {
 			gd.listener.OnTransferError(gd, err)
 			return nil
 		}
 	} else {
-		err = gd.HandleUploadFile(path, parentId, func(f *drive.File) {
+		err = gd.handleUploadConflict(path, stat, parentId, entries, func(f *drive.File) {
 			fileId = f.Id
 		})
 		if err != nil {
@@ -588,7 +1119,7 @@ func (gd *GoogleDriveClient) Upload(path string, parentId string) error {
 		}
 	}
 	gd.wg.Wait()
-	for _, tr := range gd.currentTransferQueue {
+	for _, tr := range gd.transfers() {
 		if tr.isCompleted == false {
 			return tr.err
 		}
@@ -0,0 +1,220 @@
+package gdrive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/drive/v3"
+
+	"github.com/jaskaranSM/transfer-service/logging"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+)
+
+// SetConflictPolicy decides what happens when a destination name is taken.
+// folders may be merge to reuse existing folders, files one of skip,
+// overwrite, rename or fail. Empty policies keep creating duplicates.
+func (gd *GoogleDriveClient) SetConflictPolicy(folders string, files string) error {
+	switch folders {
+	case "", gdriveconstants.ConflictMerge:
+	default:
+		return fmt.Errorf("unknown folder conflict policy: %s", folders)
+	}
+	switch files {
+	case "", gdriveconstants.ConflictSkip, gdriveconstants.ConflictOverwrite, gdriveconstants.ConflictRename, gdriveconstants.ConflictFail:
+	default:
+		return fmt.Errorf("unknown file conflict policy: %s", files)
+	}
+	gd.folderConflict = folders
+	gd.fileConflict = files
+	return nil
+}
+
+func (gd *GoogleDriveClient) needsDestEntries() bool {
+	return gd.incremental || gd.folderConflict != "" || gd.fileConflict != ""
+}
+
+// createDirIn creates the folder name in parentId, or reuses the existing
+// one when folders are merged.
+func (gd *GoogleDriveClient) createDirIn(name string, parentId string, entries *destEntries) (*drive.File, error) {
+	if entries != nil && (gd.incremental || gd.folderConflict == gdriveconstants.ConflictMerge) {
+		return gd.findOrCreateDir(name, parentId, entries)
+	}
+	return gd.CreateDir(name, parentId)
+}
+
+// uniqueName appends " (n)" in front of the extension of name until taken
+// reports the result as free.
+func uniqueName(name string, taken func(string) bool) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// conflictError fails the walk on a taken name, a dry run records the
+// conflict at path and carries on.
+func (gd *GoogleDriveClient) conflictError(name string, where string, path string) error {
+	if gd.plan != nil {
+		gd.plan.add(&PlanEntry{
+			Action: gdriveconstants.PlanConflict,
+			Path:   path,
+		}, 0)
+		return nil
+	}
+	return fmt.Errorf("conflict: %s already exists in %s", name, where)
+}
+
+// skipClaimed skips a source file whose name an earlier source of the same
+// walk already wrote, there is no finished file yet to overwrite.
+func (gd *GoogleDriveClient) skipClaimed(name string, size int64) error {
+	logging.GetLogger().Warn("Skipping file, its name was already written by this transfer", zap.String("name", name))
+	gd.skipFile(name, size)
+	return nil
+}
+
+// handleUploadConflict uploads the file at path into parentId applying the
+// file conflict policy against entries.
+func (gd *GoogleDriveClient) handleUploadConflict(path string, info os.FileInfo, parentId string, entries *destEntries, cb func(*drive.File)) error {
+	logger := logging.GetLogger()
+	if entries.claimFile(info.Name()) {
+		return gd.HandleUploadFile(path, parentId, cb)
+	}
+	existing := entries.File(info.Name())
+	switch gd.fileConflict {
+	case gdriveconstants.ConflictSkip:
+		logger.Debug("Skipping existing file", zap.String("path", path), zap.String("id", existing.Id))
+		gd.skipFile(gd.plan.destPath(parentId, info.Name()), info.Size())
+		cb(existing)
+		return nil
+	case gdriveconstants.ConflictOverwrite:
+		if existing.Id == "" {
+			return gd.skipClaimed(path, info.Size())
+		}
+		return gd.HandleUpdateFile(path, existing.Id, parentId, cb)
+	case gdriveconstants.ConflictRename:
+		name := uniqueName(info.Name(), func(n string) bool {
+			return !entries.claimFile(n)
+		})
+		return gd.handleUploadAs(path, name, parentId, cb)
+	case gdriveconstants.ConflictFail:
+		return gd.conflictError(info.Name(), parentId, gd.plan.destPath(parentId, info.Name()))
+	}
+	return gd.HandleUploadFile(path, parentId, cb)
+}
+
+// handleCloneConflict copies file into desId applying the file conflict
+// policy against entries. Copies cannot become revisions, so overwrite
+// replaces the existing file once the copy landed.
+func (gd *GoogleDriveClient) handleCloneConflict(file *drive.File, desId string, entries *destEntries, cb func(*drive.File)) error {
+	logger := logging.GetLogger()
+	if entries.claimFile(file.Name) {
+		return gd.HandleCloneFile(file, desId, cb)
+	}
+	existing := entries.File(file.Name)
+	switch gd.fileConflict {
+	case gdriveconstants.ConflictSkip:
+		logger.Debug("Skipping existing file", zap.String("name", file.Name), zap.String("id", existing.Id))
+		gd.skipFile(gd.plan.destPath(desId, file.Name), file.Size)
+		cb(existing)
+		return nil
+	case gdriveconstants.ConflictOverwrite:
+		if existing.Id == "" {
+			return gd.skipClaimed(file.Name, file.Size)
+		}
+		if gd.plan != nil {
+			gd.planClone(gdriveconstants.PlanOverwrite, file, "", desId, existing.Id)
+			return nil
+		}
+		return gd.HandleCloneFile(file, desId, func(f *drive.File) {
+			err := gd.TrashFile(existing.Id)
+			if err != nil {
+				logger.Error("Could not trash overwritten file", zap.Error(err), zap.String("fileID", existing.Id))
+			}
+			cb(f)
+		})
+	case gdriveconstants.ConflictRename:
+		name := uniqueName(file.Name, func(n string) bool {
+			return !entries.claimFile(n)
+		})
+		return gd.handleCloneAs(file, name, desId, cb)
+	case gdriveconstants.ConflictFail:
+		return gd.conflictError(file.Name, desId, gd.plan.destPath(desId, file.Name))
+	}
+	return gd.HandleCloneFile(file, desId, cb)
+}
+
+// localClaims holds the local names a download already claimed per
+// directory, so sources merged into one directory never pick the same name
+// while an earlier transfer is still in flight.
+type localClaims struct {
+	mut   sync.Mutex
+	names map[string]map[string]bool
+}
+
+// claim takes name in dir. free is false when the name was claimed before
+// or a file or partial download of that name is on disk, claimed tells the
+// two apart.
+func (c *localClaims) claim(dir string, name string) (free bool, claimed bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if c.names == nil {
+		c.names = make(map[string]map[string]bool)
+	}
+	names := c.names[dir]
+	if names == nil {
+		names = make(map[string]bool)
+		c.names[dir] = names
+	}
+	if names[name] {
+		return false, true
+	}
+	names[name] = true
+	return !localExists(filepath.Join(dir, name)), false
+}
+
+func localExists(path string) bool {
+	for _, p := range []string{path, partialPath(path)} {
+		if _, err := os.Stat(p); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// handleDownloadConflict downloads file into localDir applying the file
+// conflict policy against what is already on disk or claimed by this
+// download. Without a policy an existing file is overwritten.
+func (gd *GoogleDriveClient) handleDownloadConflict(file *drive.File, localDir string) error {
+	localName := gd.localFileName(file)
+	localPath := filepath.Join(localDir, localName)
+	free, claimed := gd.localClaims.claim(localDir, localName)
+	if free {
+		return gd.HandleDownloadFile(file, localDir)
+	}
+	switch gd.fileConflict {
+	case gdriveconstants.ConflictSkip:
+		logging.GetLogger().Debug("Skipping existing local file", zap.String("path", localPath))
+		gd.skipFile(localPath, file.Size)
+		return nil
+	case gdriveconstants.ConflictRename:
+		name := uniqueName(localName, func(n string) bool {
+			free, _ := gd.localClaims.claim(localDir, n)
+			return !free
+		})
+		return gd.handleDownloadTo(file, filepath.Join(localDir, name))
+	case gdriveconstants.ConflictFail:
+		return gd.conflictError(localName, localDir, localPath)
+	}
+	if claimed {
+		return gd.skipClaimed(localPath, file.Size)
+	}
+	return gd.HandleDownloadFile(file, localDir)
+}
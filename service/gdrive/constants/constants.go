@@ -6,4 +6,66 @@ var SADir string = "accounts"
 const TransferTypeDownloading = "download"
 const TransferTypeCloning = "clone"
 const TransferTypeUploading = "upload"
+const TransferTypeSyncUploading = "syncupload"
+const TransferTypeSyncDownloading = "syncdownload"
+const TransferTypeSyncCloning = "syncclone"
 const MaxRetries = 5
+
+const UploadChunkSize = 50 * 1024 * 1024
+const UploadURL = "https://www.googleapis.com/upload/drive/v3/files"
+const UploadSessionsDir = "uploads"
+const PartialFileSuffix = ".part"
+const PartialMetaSuffix = ".json"
+const PartialTailCheckSize = 64 * 1024
+
+const ConflictMerge = "merge"
+const ConflictSkip = "skip"
+const ConflictOverwrite = "overwrite"
+const ConflictRename = "rename"
+const ConflictFail = "fail"
+
+const GoogleAppsMimePrefix = "application/vnd.google-apps."
+
+const ShortcutMimeType = "application/vnd.google-apps.shortcut"
+const ShortcutFollow = "follow"
+const ShortcutCopy = "copy"
+const ShortcutSkip = "skip"
+
+const PlanCreateFolder = "create_folder"
+const PlanReuseFolder = "reuse_folder"
+const PlanCopy = "copy"
+const PlanRename = "rename"
+const PlanOverwrite = "overwrite"
+const PlanSkip = "skip"
+const PlanDelete = "delete"
+const PlanConflict = "conflict"
+const PlanFolderPrefix = "dryrun-folder-"
+
+const TransferTypeMoving = "move"
+const BatchJobThreshold = 50
+
+const TransferTypeTrashing = "trash"
+const TransferTypeUntrashing = "untrash"
+const TransferTypeDeleting = "delete"
+
+const TransferTypeStats = "stats"
+const StatsDefaultTop = 10
+const StatsMaxTop = 1000
+const StatsSyncMaxFolders = 100
+
+const SearchTrashedExclude = "exclude"
+const SearchTrashedOnly = "only"
+const SearchTrashedInclude = "include"
+const SearchPageSize = 100
+const SearchMaxPageSize = 1000
+
+const ListPageSize = 100
+const ListMaxPageSize = 1000
+
+const PermissionUser = "user"
+const PermissionGroup = "group"
+const PermissionDomain = "domain"
+const PermissionAnyone = "anyone"
+
+const DriveRoleContentManager = "fileOrganizer"
+const DriveMemberConcurrency = 4
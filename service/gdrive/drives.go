@@ -0,0 +1,162 @@
+package gdrive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+
+	"github.com/jaskaranSM/transfer-service/logging"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+	"github.com/jaskaranSM/transfer-service/utils"
+)
+
+const driveFields = "id,name,createdTime,hidden,restrictions"
+
+// ListDrives returns every shared drive the current credentials can see.
+func (gd *GoogleDriveClient) ListDrives() ([]*drive.Drive, error) {
+	drives := []*drive.Drive{}
+	err := gd.DriveSrv.Drives.List().PageSize(100).Fields(googleapi.Field("nextPageToken,drives("+driveFields+")")).
+		Pages(context.Background(), func(res *drive.DriveList) error {
+			drives = append(drives, res.Drives...)
+			return nil
+		})
+	if err != nil {
+		logging.GetLogger().Error("Could not list shared drives", zap.Error(err))
+		return nil, err
+	}
+	return drives, nil
+}
+
+func (gd *GoogleDriveClient) CreateDrive(name string) (*drive.Drive, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("shared drive name is empty")
+	}
+	created, err := gd.DriveSrv.Drives.Create(utils.RandString(16), &drive.Drive{Name: name}).
+		Fields(googleapi.Field(driveFields)).Do()
+	if err != nil {
+		logging.GetLogger().Error("Could not create shared drive", zap.Error(err), zap.String("name", name))
+		return nil, err
+	}
+	return created, nil
+}
+
+// ServiceAccountEmails reads the client email of every service account in
+// the accounts dir, whether or not USE_SA is set.
+func (gd *GoogleDriveClient) ServiceAccountEmails() ([]string, error) {
+	entries, err := os.ReadDir(gdriveconstants.SADir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read service accounts dir %s: %w", gdriveconstants.SADir, err)
+	}
+	var emails []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		saFile := filepath.Join(gdriveconstants.SADir, entry.Name())
+		b, err := os.ReadFile(saFile)
+		if err != nil {
+			return nil, err
+		}
+		var account struct {
+			ClientEmail string `json:"client_email"`
+		}
+		err = json.Unmarshal(b, &account)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", saFile, err)
+		}
+		if account.ClientEmail != "" {
+			emails = append(emails, account.ClientEmail)
+		}
+	}
+	if len(emails) == 0 {
+		return nil, fmt.Errorf("no service account files with a client_email in %s", gdriveconstants.SADir)
+	}
+	return emails, nil
+}
+
+// AddDriveMembers grants role on driveId to every email, content manager
+// when role is empty. Drive limits how fast sharing may happen, so a few
+// members are added at a time and rate limited calls are retried.
+func (gd *GoogleDriveClient) AddDriveMembers(driveId string, emails []string, role string) ([]*ItemResult, error) {
+	if role == "" {
+		role = gdriveconstants.DriveRoleContentManager
+	}
+	err := checkRole(role)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]*drive.File, len(emails))
+	for i, email := range emails {
+		members[i] = &drive.File{Name: email}
+	}
+	slots := make(chan int, gdriveconstants.DriveMemberConcurrency)
+	return runItemsWith(members, slots, func(member *drive.File) error {
+		permission, err := gd.addDriveMember(driveId, member.Name, role, 0)
+		if err != nil {
+			return err
+		}
+		member.Id = permission.Id
+		return nil
+	}), nil
+}
+
+func (gd *GoogleDriveClient) addDriveMember(driveId string, email string, role string, retry int) (*drive.Permission, error) {
+	logger := logging.GetLogger()
+	permission, err := gd.DriveSrv.Permissions.Create(driveId, &drive.Permission{
+		Type:         gdriveconstants.PermissionUser,
+		Role:         role,
+		EmailAddress: email,
+	}).SupportsAllDrives(true).SendNotificationEmail(false).Fields("id").Do()
+	if err != nil {
+		if retry < gdriveconstants.MaxRetries && isRetryable(err) {
+			logger.Debug("Retrying shared drive member", zap.String("email", email), zap.Int("retry", retry), zap.Error(err))
+			time.Sleep(retryDelay(retry))
+			return gd.addDriveMember(driveId, email, role, retry+1)
+		}
+		logger.Error("Could not add shared drive member", zap.Error(err),
+			zap.String("driveId", driveId), zap.String("email", email))
+		return nil, err
+	}
+	return permission, nil
+}
+
+// RemoveDriveMembers deletes the permissions of every email on driveId.
+func (gd *GoogleDriveClient) RemoveDriveMembers(driveId string, emails []string) ([]*ItemResult, error) {
+	permissions, err := gd.ListPermissions(driveId)
+	if err != nil {
+		return nil, err
+	}
+	byEmail := make(map[string]string)
+	for _, permission := range permissions {
+		if permission.EmailAddress != "" {
+			byEmail[strings.ToLower(permission.EmailAddress)] = permission.Id
+		}
+	}
+	results := make([]*ItemResult, 0, len(emails))
+	for _, email := range emails {
+		result := &ItemResult{
+			Name: email,
+		}
+		permissionId, ok := byEmail[strings.ToLower(email)]
+		if !ok {
+			result.Error = "not a member of the shared drive"
+			results = append(results, result)
+			continue
+		}
+		result.Id = permissionId
+		err = gd.DeletePermission(driveId, permissionId)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
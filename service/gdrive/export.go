@@ -0,0 +1,109 @@
+package gdrive
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/jaskaranSM/transfer-service/config"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+)
+
+// exportMimeTypes maps the extensions Google native files can be exported to
+// onto the mime type Files.Export expects.
+var exportMimeTypes = map[string]string{
+	"docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"odt":  "application/vnd.oasis.opendocument.text",
+	"rtf":  "application/rtf",
+	"pdf":  "application/pdf",
+	"txt":  "text/plain",
+	"md":   "text/markdown",
+	"zip":  "application/zip",
+	"epub": "application/epub+zip",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"ods":  "application/x-vnd.oasis.opendocument.spreadsheet",
+	"csv":  "text/csv",
+	"tsv":  "text/tab-separated-values",
+	"pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	"odp":  "application/vnd.oasis.opendocument.presentation",
+	"jpg":  "image/jpeg",
+	"png":  "image/png",
+	"svg":  "image/svg+xml",
+	"json": "application/vnd.google-apps.script+json",
+}
+
+// parseTypeList reads "document=docx,spreadsheet=xlsx" style config values.
+func parseTypeList(value string) map[string]string {
+	pairs := make(map[string]string)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, val, _ := strings.Cut(item, "=")
+		pairs[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	}
+	return pairs
+}
+
+// SetExportOptions configures how Google native files are downloaded.
+// formats maps a native type such as document or spreadsheet onto an export
+// extension and is layered over EXPORT_FORMATS, skip lists native types that
+// are left out, it replaces EXPORT_SKIP when given.
+func (gd *GoogleDriveClient) SetExportOptions(formats map[string]string, skip []string, acknowledgeAbuse bool) error {
+	cfg := config.Get()
+	gd.exportFormats = parseTypeList(cfg.ExportFormats)
+	for kind, ext := range formats {
+		gd.exportFormats[kind] = ext
+	}
+	for kind, ext := range gd.exportFormats {
+		if _, ok := exportMimeTypes[ext]; !ok {
+			return fmt.Errorf("unknown export format %s for %s", ext, kind)
+		}
+	}
+	if skip == nil {
+		skip = strings.Split(cfg.ExportSkip, ",")
+	}
+	gd.exportSkip = make(map[string]bool)
+	for _, kind := range skip {
+		gd.exportSkip[strings.TrimSpace(kind)] = true
+	}
+	gd.acknowledgeAbuse = acknowledgeAbuse
+	return nil
+}
+
+func IsGoogleNative(file *drive.File) bool {
+	return strings.HasPrefix(file.MimeType, gdriveconstants.GoogleAppsMimePrefix)
+}
+
+func nativeKind(file *drive.File) string {
+	return strings.TrimPrefix(file.MimeType, gdriveconstants.GoogleAppsMimePrefix)
+}
+
+// exportFor returns the extension file is exported to. skip is set for
+// native types on the skip list or without an export mapping.
+func (gd *GoogleDriveClient) exportFor(file *drive.File) (ext string, skip bool) {
+	if !IsGoogleNative(file) {
+		return "", false
+	}
+	kind := nativeKind(file)
+	if gd.exportSkip[kind] {
+		return "", true
+	}
+	ext, ok := gd.exportFormats[kind]
+	if !ok {
+		return "", true
+	}
+	return ext, false
+}
+
+// localFileName is the name file gets on disk, exports carry the extension
+// of their format.
+func (gd *GoogleDriveClient) localFileName(file *drive.File) string {
+	ext, _ := gd.exportFor(file)
+	if ext == "" || strings.HasSuffix(strings.ToLower(file.Name), "."+ext) {
+		return file.Name
+	}
+	return file.Name + "." + ext
+}
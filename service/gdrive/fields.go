@@ -0,0 +1,63 @@
+package gdrive
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultFileFields are returned when a listing selects no fields.
+var DefaultFileFields = []string{"id", "name", "mimeType", "size", "modifiedTime"}
+
+var fieldPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(/[A-Za-z][A-Za-z0-9]*)*$`)
+
+var orderKeys = map[string]bool{
+	"createdTime":      true,
+	"folder":           true,
+	"modifiedByMeTime": true,
+	"modifiedTime":     true,
+	"name":             true,
+	"name_natural":     true,
+	"quotaBytesUsed":   true,
+	"recency":          true,
+	"sharedWithMeTime": true,
+	"starred":          true,
+	"viewedByMeTime":   true,
+}
+
+// FileFields checks the requested Drive file fields such as parents or
+// owners/emailAddress and adds id, name and mimeType which walks and
+// clients rely on.
+func FileFields(fields []string) ([]string, error) {
+	if len(fields) == 0 {
+		return DefaultFileFields, nil
+	}
+	selected := []string{"id", "name", "mimeType"}
+	seen := map[string]bool{"id": true, "name": true, "mimeType": true}
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if !fieldPattern.MatchString(field) {
+			return nil, fmt.Errorf("invalid field: %q", field)
+		}
+		if seen[field] {
+			continue
+		}
+		seen[field] = true
+		selected = append(selected, field)
+	}
+	return selected, nil
+}
+
+// ParseOrderBy checks a Drive ordering such as "folder,name" or
+// "modifiedTime desc".
+func ParseOrderBy(orderBy string) (string, error) {
+	var keys []string
+	for _, key := range strings.Split(orderBy, ",") {
+		parts := strings.Fields(key)
+		if len(parts) == 0 || len(parts) > 2 || !orderKeys[parts[0]] || (len(parts) == 2 && parts[1] != "desc") {
+			return "", fmt.Errorf("invalid order: %q", strings.TrimSpace(key))
+		}
+		keys = append(keys, strings.Join(parts, " "))
+	}
+	return strings.Join(keys, ","), nil
+}
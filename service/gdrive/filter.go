@@ -0,0 +1,47 @@
+package gdrive
+
+import (
+	"os"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/jaskaranSM/transfer-service/utils"
+)
+
+// SetFilter restricts folder walks and folder sizing to the entries filter
+// matches, a nil filter walks everything.
+func (gd *GoogleDriveClient) SetFilter(filter *utils.Filter) {
+	gd.filter = filter
+}
+
+// joinRel builds the slash separated path below the walk root that filters
+// are matched against.
+func joinRel(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "/" + name
+}
+
+func (gd *GoogleDriveClient) matchDriveFile(file *drive.File, relPath string) bool {
+	if gd.IsDir(file) {
+		return gd.filter.MatchDir(relPath)
+	}
+	modifiedTime, _ := time.Parse(time.RFC3339, file.ModifiedTime)
+	return gd.filter.MatchFile(relPath, file.Size, modifiedTime)
+}
+
+func (gd *GoogleDriveClient) matchLocalFile(relPath string, entry os.DirEntry) (bool, error) {
+	if gd.filter == nil {
+		return true, nil
+	}
+	if entry.IsDir() {
+		return gd.filter.MatchDir(relPath), nil
+	}
+	info, err := entry.Info()
+	if err != nil {
+		return false, err
+	}
+	return gd.filter.MatchFile(relPath, info.Size(), info.ModTime()), nil
+}
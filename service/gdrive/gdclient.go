@@ -2,28 +2,28 @@ package gdrive
 
 import (
 	"context"
+	"hash"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
-	"path/filepath"
+	"sync"
 
 	"go.uber.org/zap"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/drive/v3"
-	"google.golang.org/api/googleapi"
 
 	"github.com/jaskaranSM/transfer-service/logging"
 	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
 
 	"github.com/jaskaranSM/transfer-service/constants"
-	"github.com/jaskaranSM/transfer-service/utils"
 )
 
-func NewGoogleDriveFileTransfer(service *drive.Service, listener FileTransferListener, cb func(*drive.File)) *GoogleDriveFileTransfer {
+func NewGoogleDriveFileTransfer(service *drive.Service, httpClient *http.Client, listener FileTransferListener, cb func(*drive.File)) *GoogleDriveFileTransfer {
 	return &GoogleDriveFileTransfer{
 		service:            service,
+		httpClient:         httpClient,
 		listener:           listener,
 		onTransferComplete: cb,
 	}
@@ -31,6 +31,7 @@ func NewGoogleDriveFileTransfer(service *drive.Service, listener FileTransferLis
 
 type GoogleDriveFileTransfer struct {
 	service            *drive.Service
+	httpClient         *http.Client
 	completed          int64
 	file               *os.File
 	fileId             string
@@ -41,6 +42,17 @@ type GoogleDriveFileTransfer struct {
 	listener           FileTransferListener
 	isCancelled        bool
 	onTransferComplete func(*drive.File)
+	mut                sync.Mutex
+	name               string
+	verify             bool
+	hash               hash.Hash
+	hashed             int64
+	hashState          []byte
+	hashStateAt        int64
+	updateFileId       string
+	targetName         string
+	exportMime         string
+	acknowledgeAbuse   bool
 }
 
 func (g *GoogleDriveFileTransfer) clean() {
@@ -56,20 +68,41 @@ func (g *GoogleDriveFileTransfer) CompletedLength() int64 {
 	return g.completed
 }
 
+func (g *GoogleDriveFileTransfer) Name() string {
+	return g.name
+}
+
+// syncProgress moves the reported progress to offset, used when a transfer
+// resumes from bytes that are already on the other side.
+func (g *GoogleDriveFileTransfer) syncProgress(offset int64) {
+	g.mut.Lock()
+	defer g.mut.Unlock()
+	if offset == g.completed {
+		return
+	}
+	g.listener.OnTransferUpdate(g, offset-g.completed)
+	g.completed = offset
+}
+
+func (g *GoogleDriveFileTransfer) addProgress(chunk int64) {
+	g.mut.Lock()
+	g.completed += chunk
+	g.mut.Unlock()
+	g.listener.OnTransferUpdate(g, chunk)
+}
+
 func (g *GoogleDriveFileTransfer) Write(p []byte) (int, error) {
 	logger := logging.GetLogger()
 	if g.isCancelled {
-		err := constants.CancelledByUserError
-		g.listener.OnTransferError(g, err)
-		return 0, err
+		return 0, constants.CancelledByUserError
 	}
 	bytesWritten, err := g.file.Write(p)
+	g.updateHash(p[:bytesWritten])
 	g.completed += int64(bytesWritten)
 	logger.Debug("on transfer update: ", zap.Int("chunk_written", bytesWritten))
 	g.listener.OnTransferUpdate(g, int64(bytesWritten))
 	if err != nil && err != io.EOF {
 		logger.Error("Error while writing file bytes", zap.Error(err), zap.String("filepath", g.file.Name()))
-		g.listener.OnTransferError(g, err)
 	}
 	return bytesWritten, err
 }
@@ -77,17 +110,15 @@ func (g *GoogleDriveFileTransfer) Write(p []byte) (int, error) {
 func (g *GoogleDriveFileTransfer) Read(p []byte) (int, error) {
 	logger := logging.GetLogger()
 	if g.isCancelled {
-		err := constants.CancelledByUserError
-		g.listener.OnTransferError(g, err)
-		return 0, err
+		return 0, constants.CancelledByUserError
 	}
 	bytesRead, err := g.file.Read(p)
+	g.updateHash(p[:bytesRead])
 	g.completed += int64(bytesRead)
 	logger.Debug("on transfer update: ", zap.Int("chunk_read", bytesRead))
 	g.listener.OnTransferUpdate(g, int64(bytesRead))
 	if err != nil && err != io.EOF {
 		logger.Error("Error while reading file bytes", zap.Error(err), zap.String("filepath", g.file.Name()))
-		g.listener.OnTransferError(g, err)
 	}
 	return bytesRead, err
 }
@@ -99,13 +130,17 @@ func (g *GoogleDriveFileTransfer) Cancel() {
 func (g *GoogleDriveFileTransfer) Clone(file *drive.File, desId string, retry int) {
 	logger := logging.GetLogger()
 	g.transferType = gdriveconstants.TransferTypeCloning
+	g.name = file.Name
 	logger.Info("on transfer start", zap.String("fileID", file.Id))
 	g.listener.OnTransferStart(g)
 	fileSize := file.Size
 	f := &drive.File{
-		Parents: []string{desId},
+		Name:         g.targetName,
+		Parents:      []string{desId},
+		ModifiedTime: file.ModifiedTime,
 	}
 	newFile, err := g.service.Files.Copy(file.Id, f).Fields("*").SupportsAllDrives(true).SupportsTeamDrives(true).Do()
+	invalidateMetadata(desId)
 	if err != nil {
 		if retry < gdriveconstants.MaxRetries && err != constants.CancelledByUserError {
 			g.listener.OnTransferUpdate(g, g.completed*-1)
@@ -119,6 +154,19 @@ func (g *GoogleDriveFileTransfer) Clone(file *drive.File, desId string, retry in
 		g.listener.OnTransferError(g, err)
 		return
 	}
+	err = g.compareChecksums(file.Name, file.Md5Checksum, newFile.Md5Checksum)
+	if err != nil {
+		g.discardFile(newFile.Id)
+		if retry < gdriveconstants.MaxRetries {
+			logger.Debug("files:copy: Retrying clone after checksum mismatch", zap.String("fileID", file.Id), zap.Int("retry", retry), zap.Error(err))
+			g.Clone(file, desId, retry+1)
+			return
+		}
+		g.err = err
+		logger.Error("Copied file failed verification", zap.Error(err), zap.String("fileID", file.Id))
+		g.listener.OnTransferError(g, err)
+		return
+	}
 	g.listener.OnTransferUpdate(g, fileSize)
 	g.fileId = newFile.Id
 	g.completed = fileSize
@@ -131,8 +179,12 @@ func (g *GoogleDriveFileTransfer) Clone(file *drive.File, desId string, retry in
 func (g *GoogleDriveFileTransfer) Download(file *drive.File, path string, retry int) {
 	logger := logging.GetLogger()
 	g.transferType = gdriveconstants.TransferTypeDownloading
-	fileHandle, err := os.Create(path)
+	g.name = path
+	offset, err := g.openPartialFile(file, path)
 	if err != nil {
+		if g.file != nil {
+			g.file.Close()
+		}
 		logger.Error("Error while opening file handle",
 			zap.Error(err),
 			zap.String("filepath", path),
@@ -141,17 +193,15 @@ func (g *GoogleDriveFileTransfer) Download(file *drive.File, path string, retry
 		g.listener.OnTransferError(g, err)
 		return
 	}
-	g.file = fileHandle
 	if retry == 0 {
 		logger.Debug("on Transfer Start:", zap.String("fileID", file.Id))
 		g.listener.OnTransferStart(g)
 	}
-	res, err := g.service.Files.Get(file.Id).SupportsAllDrives(true).SupportsTeamDrives(true).Download()
+	g.syncProgress(offset)
+	body, err := g.openDownloadStream(file, offset)
 	if err != nil {
 		if retry < gdriveconstants.MaxRetries && err != constants.CancelledByUserError {
 			g.file.Close()
-			g.listener.OnTransferUpdate(g, g.completed*-1)
-			g.completed = 0
 			logger.Debug("Files:Get: Retrying download transfer", zap.Any("file", file), zap.String("path", path), zap.Int("retry", retry))
 			g.Download(file, path, retry+1)
 			return
@@ -162,13 +212,14 @@ func (g *GoogleDriveFileTransfer) Download(file *drive.File, path string, retry
 		g.listener.OnTransferError(g, err)
 		return
 	}
-	defer res.Body.Close()
-	_, err = io.Copy(g, res.Body)
+	defer body.Close()
+	err = g.resetHash(g.completed)
+	if err == nil {
+		_, err = io.Copy(g, body)
+	}
 	if err != nil {
 		if retry < gdriveconstants.MaxRetries && err != constants.CancelledByUserError {
 			g.file.Close()
-			g.listener.OnTransferUpdate(g, g.completed*-1)
-			g.completed = 0
 			logger.Debug("io:copy: Retrying download transfer", zap.Any("file", file), zap.String("path", path), zap.Int("retry", retry))
 			g.Download(file, path, retry+1)
 			return
@@ -179,7 +230,27 @@ func (g *GoogleDriveFileTransfer) Download(file *drive.File, path string, retry
 		g.listener.OnTransferError(g, err)
 		return
 	}
+	err = g.checkChecksum(path, file.Md5Checksum, file.Size)
 	g.file.Close()
+	if err != nil {
+		discardPartialFile(path)
+		if retry < gdriveconstants.MaxRetries {
+			logger.Debug("Retrying download after checksum mismatch", zap.String("path", path), zap.Int("retry", retry), zap.Error(err))
+			g.Download(file, path, retry+1)
+			return
+		}
+		g.err = err
+		logger.Error("Downloaded file failed verification", zap.Error(err), zap.String("path", path))
+		g.listener.OnTransferError(g, err)
+		return
+	}
+	err = finishPartialFile(path, file)
+	if err != nil {
+		g.err = err
+		logger.Error("Error while moving partial file into place", zap.Error(err), zap.String("path", path))
+		g.listener.OnTransferError(g, err)
+		return
+	}
 	g.isCompleted = true
 	logger.Debug("on transfer complete", zap.String("path", path))
 	g.listener.OnTransferComplete(g)
@@ -188,6 +259,7 @@ func (g *GoogleDriveFileTransfer) Download(file *drive.File, path string, retry
 func (g *GoogleDriveFileTransfer) Upload(path string, parentId string, retry int) {
 	logger := logging.GetLogger()
 	g.transferType = gdriveconstants.TransferTypeUploading
+	g.name = path
 	fileHandle, err := os.Open(path)
 	if err != nil {
 		g.err = err
@@ -203,19 +275,20 @@ func (g *GoogleDriveFileTransfer) Upload(path string, parentId string, retry int
 		logger.Debug("on Transfer Start:", zap.String("path", path))
 		g.listener.OnTransferStart(g)
 	}
-	contentType := utils.GetFileContentTypePath(path)
-	f := &drive.File{
-		MimeType: contentType,
-		Name:     filepath.Base(path),
-		Parents:  []string{parentId},
+	stat, err := fileHandle.Stat()
+	if err != nil {
+		g.file.Close()
+		g.err = err
+		logger.Error("Error while getting file stats", zap.Error(err), zap.String("filepath", path))
+		g.listener.OnTransferError(g, err)
+		return
 	}
-	file, err := g.service.Files.Create(f).SupportsAllDrives(true).SupportsTeamDrives(true).Media(g, googleapi.ChunkSize(50*1024*1024)).Do()
+	file, err := g.resumableUpload(path, parentId, stat)
+	invalidateMetadata(parentId, g.updateFileId)
 	if err != nil {
 		if retry < gdriveconstants.MaxRetries && err != constants.CancelledByUserError {
 			g.file.Close()
-			g.listener.OnTransferUpdate(g, g.completed*-1)
-			g.completed = 0
-			logger.Debug("files:create: Retrying upload transfer", zap.Any("path", path), zap.String("parentId", parentId), zap.Int("retry", retry))
+			logger.Debug("resumable: Retrying upload transfer", zap.Any("path", path), zap.String("parentId", parentId), zap.Int("retry", retry), zap.Error(err))
 			g.Upload(path, parentId, retry+1)
 			return
 		}
@@ -225,6 +298,23 @@ func (g *GoogleDriveFileTransfer) Upload(path string, parentId string, retry int
 		g.listener.OnTransferError(g, err)
 		return
 	}
+	removeUploadSession(uploadSessionKey(path, parentId, g.updateFileId, stat))
+	err = g.checkChecksum(path, file.Md5Checksum, stat.Size())
+	if err != nil {
+		if g.updateFileId == "" {
+			g.discardFile(file.Id)
+		}
+		g.file.Close()
+		if retry < gdriveconstants.MaxRetries {
+			logger.Debug("Retrying upload after checksum mismatch", zap.String("path", path), zap.Int("retry", retry), zap.Error(err))
+			g.Upload(path, parentId, retry+1)
+			return
+		}
+		g.err = err
+		logger.Error("Uploaded file failed verification", zap.Error(err), zap.String("path", path))
+		g.listener.OnTransferError(g, err)
+		return
+	}
 	g.file.Close()
 	g.fileId = file.Id
 	g.isCompleted = true
@@ -0,0 +1,160 @@
+package gdrive
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/drive/v3"
+
+	"github.com/jaskaranSM/transfer-service/logging"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+	"github.com/jaskaranSM/transfer-service/utils"
+)
+
+// destEntries indexes the non trashed children of a destination folder by
+// name, folders and files are kept apart since Drive allows both to share a
+// name. Entries the walk creates are added so later sources with the same
+// name find them.
+type destEntries struct {
+	mut     sync.Mutex
+	folders map[string]*drive.File
+	files   map[string]*drive.File
+	all     []*drive.File
+}
+
+func (d *destEntries) Folder(name string) *drive.File {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	return d.folders[name]
+}
+
+func (d *destEntries) File(name string) *drive.File {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	return d.files[name]
+}
+
+// claimFile records name as taken in the folder before a transfer writes
+// it, it reports false when a file of that name is there or was claimed by
+// an earlier source. Without entries every name is free.
+func (d *destEntries) claimFile(name string) bool {
+	if d == nil {
+		return true
+	}
+	d.mut.Lock()
+	defer d.mut.Unlock()
+	if _, ok := d.files[name]; ok {
+		return false
+	}
+	d.files[name] = &drive.File{Name: name}
+	return true
+}
+
+func (gd *GoogleDriveClient) listDestEntries(parentId string) (*destEntries, error) {
+	var files []*drive.File
+	var err error
+	// folders a dry run would create are empty
+	if !isPlannedFolder(parentId) {
+		files, err = gd.listFiles(fmt.Sprintf("'%s' in parents and trashed = false", escapeQuery(parentId)), -1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	entries := &destEntries{
+		folders: make(map[string]*drive.File),
+		files:   make(map[string]*drive.File),
+		all:     files,
+	}
+	for _, file := range files {
+		target := entries.files
+		if gd.IsDir(file) {
+			target = entries.folders
+		}
+		// listing is newest first, keep the most recent of duplicate names
+		if _, ok := target[file.Name]; !ok {
+			target[file.Name] = file
+		}
+	}
+	return entries, nil
+}
+
+// findOrCreateDir reuses the folder called name in entries or creates it,
+// a created folder is reused by later sources with the same name.
+func (gd *GoogleDriveClient) findOrCreateDir(name string, parentId string, entries *destEntries) (*drive.File, error) {
+	entries.mut.Lock()
+	defer entries.mut.Unlock()
+	if dir := entries.folders[name]; dir != nil {
+		logging.GetLogger().Debug("Reusing existing folder", zap.String("name", name), zap.String("id", dir.Id))
+		if gd.plan != nil {
+			gd.plan.addFolder(gdriveconstants.PlanReuseFolder, dir.Id, parentId, name)
+		}
+		return dir, nil
+	}
+	dir, err := gd.CreateDir(name, parentId)
+	if err != nil {
+		return nil, err
+	}
+	entries.folders[name] = dir
+	return dir, nil
+}
+
+// isUploaded reports whether existing already holds the content of the local
+// file at path, compared by size and, when enabled, md5.
+func (gd *GoogleDriveClient) isUploaded(path string, info os.FileInfo, existing *drive.File) bool {
+	if existing == nil || existing.Size != info.Size() {
+		return false
+	}
+	if !gd.compareMd5 {
+		return !gd.compareModTime || sameModTime(info.ModTime(), existing.ModifiedTime)
+	}
+	sum, err := utils.GetFileMd5(path)
+	if err != nil {
+		logging.GetLogger().Error("Could not hash local file", zap.Error(err), zap.String("path", path))
+		return false
+	}
+	return sum == existing.Md5Checksum
+}
+
+// sameModTime compares a local mtime with a Drive modifiedTime, Drive only
+// keeps millisecond precision.
+func sameModTime(local time.Time, modifiedTime string) bool {
+	remote, err := time.Parse(time.RFC3339, modifiedTime)
+	if err != nil {
+		return false
+	}
+	diff := local.Sub(remote)
+	return diff > -time.Millisecond && diff < time.Millisecond
+}
+
+// skipFile counts a file that did not need a transfer as done, path is what
+// a dry run reports for it.
+func (gd *GoogleDriveClient) skipFile(path string, size int64) {
+	gd.plan.add(&PlanEntry{
+		Action: gdriveconstants.PlanSkip,
+		Path:   path,
+		Size:   size,
+	}, 0)
+	gd.mut.Lock()
+	defer gd.mut.Unlock()
+	gd.skippedFiles += 1
+	gd.completed += size
+}
+
+// handleIncrementalUpload uploads the file at path unless an identical copy is
+// already in entries, a changed copy gets the new content as a revision.
+func (gd *GoogleDriveClient) handleIncrementalUpload(path string, info os.FileInfo, parentId string, entries *destEntries, cb func(*drive.File)) error {
+	existing := entries.File(info.Name())
+	if gd.isUploaded(path, info, existing) {
+		logging.GetLogger().Debug("Skipping already uploaded file", zap.String("path", path), zap.String("id", existing.Id))
+		gd.skipFile(gd.plan.destPath(parentId, info.Name()), info.Size())
+		cb(existing)
+		return nil
+	}
+	if existing != nil {
+		return gd.HandleUpdateFile(path, existing.Id, parentId, cb)
+	}
+	return gd.HandleUploadFile(path, parentId, cb)
+}
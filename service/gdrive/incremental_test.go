@@ -0,0 +1,45 @@
+package gdrive
+
+import (
+	"testing"
+)
+
+func TestSameNamedSourceFoldersShareDestination(t *testing.T) {
+	gd := &GoogleDriveClient{}
+	gd.SetDryRun(true)
+	gd.incremental = true
+	parent, err := gd.CreateDir("dest", "root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := gd.listDestEntries(parent.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := &walkItem{
+		src: "src",
+		des: parent.Id,
+	}
+	var children []*walkItem
+	for _, src := range []string{"a1", "a2"} {
+		dir, err := gd.createDirIn("a", parent.Id, entries)
+		if err != nil {
+			t.Fatal(err)
+		}
+		children = addChild(children, root, src, dir.Id, "a")
+	}
+	if len(children) != 1 {
+		t.Fatalf("got %d destination folders, want 1", len(children))
+	}
+	if got := children[0].sources(); len(got) != 2 || got[0] != "a1" || got[1] != "a2" {
+		t.Errorf("sources = %v, want [a1 a2]", got)
+	}
+	if entries.Folder("a") == nil || entries.Folder("a").Id != children[0].des {
+		t.Errorf("created folder is not recorded in the destination entries")
+	}
+	// dest and a are created once, the second a reuses the first
+	summary := gd.plan.Summary()
+	if summary.FoldersToCreate != 2 || summary.FoldersToReuse != 1 {
+		t.Errorf("planned %d new and %d reused folders, want 2 and 1", summary.FoldersToCreate, summary.FoldersToReuse)
+	}
+}
@@ -0,0 +1,157 @@
+package gdrive
+
+import (
+	"sync"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/jaskaranSM/transfer-service/config"
+)
+
+// metadataCache keeps file metadata by id and full folder listings by parent
+// id for METADATA_CACHE_TTL seconds, it is shared by all clients so sizing a
+// tree and then transferring it lists every folder once. Entries the service
+// changes itself are invalidated right away.
+type metadataCache struct {
+	mut       sync.Mutex
+	files     map[string]*metadataEntry
+	children  map[string]*metadataEntry
+	listedIn  map[string]string
+	lastSweep time.Time
+}
+
+type metadataEntry struct {
+	files   []*drive.File
+	expires time.Time
+}
+
+var metadata = &metadataCache{
+	files:    make(map[string]*metadataEntry),
+	children: make(map[string]*metadataEntry),
+	listedIn: make(map[string]string),
+}
+
+func metadataTTL() time.Duration {
+	return time.Duration(config.Get().MetadataCacheTTL) * time.Second
+}
+
+// copyFiles hands out copies so callers can not modify cached entries.
+func copyFiles(files []*drive.File) []*drive.File {
+	copies := make([]*drive.File, len(files))
+	for i, file := range files {
+		f := *file
+		copies[i] = &f
+	}
+	return copies
+}
+
+func (c *metadataCache) lookup(entries map[string]*metadataEntry, key string) ([]*drive.File, bool) {
+	entry, ok := entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(entries, key)
+		return nil, false
+	}
+	return copyFiles(entry.files), true
+}
+
+func (c *metadataCache) getFile(fileId string) (*drive.File, bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	files, ok := c.lookup(c.files, fileId)
+	if !ok {
+		return nil, false
+	}
+	return files[0], true
+}
+
+func (c *metadataCache) getChildren(parentId string) ([]*drive.File, bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.lookup(c.children, parentId)
+}
+
+func (c *metadataCache) putFile(file *drive.File) {
+	ttl := metadataTTL()
+	if ttl <= 0 {
+		return
+	}
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.sweep(ttl)
+	c.files[file.Id] = &metadataEntry{
+		files:   copyFiles([]*drive.File{file}),
+		expires: time.Now().Add(ttl),
+	}
+}
+
+// putChildren caches the complete listing of parentId, its entries are
+// cached by id as well.
+func (c *metadataCache) putChildren(parentId string, files []*drive.File) {
+	ttl := metadataTTL()
+	if ttl <= 0 {
+		return
+	}
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.sweep(ttl)
+	expires := time.Now().Add(ttl)
+	c.children[parentId] = &metadataEntry{
+		files:   copyFiles(files),
+		expires: expires,
+	}
+	for _, file := range files {
+		c.files[file.Id] = &metadataEntry{
+			files:   copyFiles([]*drive.File{file}),
+			expires: expires,
+		}
+		c.listedIn[file.Id] = parentId
+	}
+}
+
+// sweep drops expired entries once per ttl so large trees do not linger.
+func (c *metadataCache) sweep(ttl time.Duration) {
+	now := time.Now()
+	if now.Sub(c.lastSweep) < ttl {
+		return
+	}
+	c.lastSweep = now
+	for key, entry := range c.files {
+		if now.After(entry.expires) {
+			delete(c.files, key)
+			delete(c.listedIn, key)
+		}
+	}
+	for key, entry := range c.children {
+		if now.After(entry.expires) {
+			delete(c.children, key)
+		}
+	}
+}
+
+// invalidateMetadata forgets the given ids, their listings and the listings
+// they appear in, along with the resolved paths through them. It is called
+// for every entry the service creates in, renames, moves or removes from a
+// folder, with the folder id for new entries.
+func invalidateMetadata(ids ...string) {
+	forget := make(map[string]bool)
+	metadata.mut.Lock()
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		forget[id] = true
+		delete(metadata.files, id)
+		delete(metadata.children, id)
+		if parentId, ok := metadata.listedIn[id]; ok {
+			forget[parentId] = true
+			delete(metadata.children, parentId)
+			delete(metadata.listedIn, id)
+		}
+	}
+	metadata.mut.Unlock()
+	drivePaths.forget(forget)
+}
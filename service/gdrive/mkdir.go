@@ -0,0 +1,75 @@
+package gdrive
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// mkdirMut keeps concurrent requests for the same path from each creating
+// the missing folders.
+var mkdirMut sync.Mutex
+
+// splitFolderPath splits a slash separated folder path into its names.
+func splitFolderPath(folderPath string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(folderPath, "/") {
+		if name == "" || name == "." {
+			continue
+		}
+		if name == ".." {
+			return nil, errors.New("folder path can not contain ..")
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, errors.New("folder path is empty")
+	}
+	return names, nil
+}
+
+// findFolder returns the non trashed folder called name in parentId, nil when
+// there is none.
+func (gd *GoogleDriveClient) findFolder(parentId string, name string) (*drive.File, error) {
+	query := fmt.Sprintf("'%s' in parents and name = '%s' and mimeType = 'application/vnd.google-apps.folder' and trashed = false", escapeQuery(parentId), escapeQuery(name))
+	files, err := gd.listFiles(query, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+	return files[0], nil
+}
+
+// MkdirAll walks folderPath below parentId, reusing the folders that exist
+// and creating the missing ones. It returns the leaf folder and the paths of
+// the folders it created.
+func (gd *GoogleDriveClient) MkdirAll(parentId string, folderPath string) (*drive.File, []string, error) {
+	names, err := splitFolderPath(folderPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	mkdirMut.Lock()
+	defer mkdirMut.Unlock()
+	var created []string
+	var dir *drive.File
+	for i, name := range names {
+		dir, err = gd.findFolder(parentId, name)
+		if err != nil {
+			return nil, created, err
+		}
+		if dir == nil {
+			dir, err = gd.CreateDir(name, parentId)
+			if err != nil {
+				return nil, created, err
+			}
+			created = append(created, strings.Join(names[:i+1], "/"))
+		}
+		parentId = dir.Id
+	}
+	return dir, created, nil
+}
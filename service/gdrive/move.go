@@ -0,0 +1,207 @@
+package gdrive
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/drive/v3"
+
+	"github.com/jaskaranSM/transfer-service/logging"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+)
+
+const moveFields = "id,name,mimeType,size,parents"
+
+// ItemResult is the outcome of a batch operation on one item.
+type ItemResult struct {
+	Id    string `json:"id"`
+	Name  string `json:"name"`
+	Error string `json:"error,omitempty"`
+}
+
+// RenameFile changes the name of a file or folder in place.
+func (gd *GoogleDriveClient) RenameFile(fileId string, name string) (*drive.File, error) {
+	logger := logging.GetLogger()
+	if name == "" {
+		return nil, errors.New("new name is empty")
+	}
+	file, err := gd.DriveSrv.Files.Update(fileId, &drive.File{Name: name}).Fields(moveFields).SupportsAllDrives(true).Do()
+	invalidateMetadata(fileId)
+	if err != nil {
+		logger.Error("Could not rename file", zap.Error(err), zap.String("fileID", fileId), zap.String("name", name))
+		return nil, err
+	}
+	return file, nil
+}
+
+// ResolveFiles fetches the items named by fileIds, which may be ids or Drive
+// paths, and the ones matched by the Drive query. The query leaves trashed
+// items out unless includeTrashed is set. Duplicates are dropped.
+func (gd *GoogleDriveClient) ResolveFiles(fileIds []string, query string, includeTrashed bool) ([]*drive.File, error) {
+	var files []*drive.File
+	seen := make(map[string]bool)
+	for _, ref := range fileIds {
+		fileId, err := gd.ResolveId(ref)
+		if err != nil {
+			return nil, err
+		}
+		if seen[fileId] {
+			continue
+		}
+		file, err := gd.DriveSrv.Files.Get(fileId).Fields(moveFields).SupportsAllDrives(true).Do()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", fileId, err)
+		}
+		seen[fileId] = true
+		files = append(files, file)
+	}
+	if query != "" {
+		if !includeTrashed {
+			query = fmt.Sprintf("(%s) and trashed = false", query)
+		}
+		matched, err := gd.listFilesWithFields(query, -1, "nextPageToken,files("+moveFields+")")
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range matched {
+			if seen[file.Id] {
+				continue
+			}
+			seen[file.Id] = true
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// MoveFile puts file into desId and takes it out of all its current parents,
+// which also moves it across shared drives.
+func (gd *GoogleDriveClient) MoveFile(file *drive.File, desId string) (*drive.File, error) {
+	return gd.moveFile(file, desId, 0)
+}
+
+func (gd *GoogleDriveClient) moveFile(file *drive.File, desId string, retry int) (*drive.File, error) {
+	logger := logging.GetLogger()
+	call := gd.DriveSrv.Files.Update(file.Id, &drive.File{}).AddParents(desId).Fields(moveFields).SupportsAllDrives(true)
+	var oldParents []string
+	for _, parent := range file.Parents {
+		if parent != desId {
+			oldParents = append(oldParents, parent)
+		}
+	}
+	if len(oldParents) != 0 {
+		call = call.RemoveParents(strings.Join(oldParents, ","))
+	}
+	moved, err := call.Do()
+	invalidateMetadata(append([]string{file.Id, desId}, file.Parents...)...)
+	if err != nil {
+		if retry < gdriveconstants.MaxRetries && isRetryable(err) {
+			logger.Debug("Retrying move", zap.String("fileID", file.Id), zap.Int("retry", retry), zap.Error(err))
+			time.Sleep(retryDelay(retry))
+			return gd.moveFile(file, desId, retry+1)
+		}
+		logger.Error("Could not move file", zap.Error(err), zap.String("fileID", file.Id), zap.String("desId", desId))
+		return nil, err
+	}
+	return moved, nil
+}
+
+// MoveFiles moves files into desId and reports every item, it is meant for
+// batches small enough to wait for.
+func (gd *GoogleDriveClient) MoveFiles(files []*drive.File, desId string) []*ItemResult {
+	return gd.runItems(files, func(file *drive.File) error {
+		_, err := gd.MoveFile(file, desId)
+		return err
+	})
+}
+
+// Move runs a batch move as a tracked job.
+func (gd *GoogleDriveClient) Move(files []*drive.File, desId string) error {
+	logging.GetLogger().Info("starting move", zap.Int("items", len(files)), zap.String("desId", desId))
+	return gd.runItemsJob("moving", files, desId, func(file *drive.File) error {
+		_, err := gd.MoveFile(file, desId)
+		return err
+	})
+}
+
+// runItems applies fn to files using the client's concurrency.
+func (gd *GoogleDriveClient) runItems(files []*drive.File, fn func(*drive.File) error) []*ItemResult {
+	return runItemsWith(files, gd.concurrency, fn)
+}
+
+// runItemsWith applies fn to files with at most cap(slots) in flight, fn may
+// set the id reported for its item.
+func runItemsWith(files []*drive.File, slots chan int, fn func(*drive.File) error) []*ItemResult {
+	results := make([]*ItemResult, len(files))
+	var wg sync.WaitGroup
+	for i, file := range files {
+		slots <- 1
+		wg.Add(1)
+		go func(i int, file *drive.File) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			err := fn(file)
+			result := &ItemResult{
+				Id:   file.Id,
+				Name: file.Name,
+			}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			results[i] = result
+		}(i, file)
+	}
+	wg.Wait()
+	return results
+}
+
+// runItemsJob is runItems reporting to the listener, each item counts as a
+// completed or failed file and the job fails if any item failed.
+func (gd *GoogleDriveClient) runItemsJob(verb string, files []*drive.File, resultId string, fn func(*drive.File) error) error {
+	gd.Name = fmt.Sprintf("%s %d items", verb, len(files))
+	gd.listener.OnTransferStart(gd)
+	for _, file := range files {
+		if gd.isCancelled {
+			break
+		}
+		gd.concurrency <- 1
+		gd.wg.Add(1)
+		go func(file *drive.File) {
+			defer func() {
+				<-gd.concurrency
+				gd.wg.Done()
+			}()
+			err := fn(file)
+			gd.mut.Lock()
+			defer gd.mut.Unlock()
+			if err != nil {
+				gd.failedFiles = append(gd.failedFiles, &FailedFile{
+					Name:  file.Name,
+					Error: err.Error(),
+				})
+				return
+			}
+			gd.completedFiles += 1
+			gd.completed += file.Size
+		}(file)
+	}
+	gd.wg.Wait()
+	if gd.isCancelled {
+		err := errors.New("cancelled by user")
+		gd.listener.OnTransferError(gd, err)
+		return err
+	}
+	if failed := len(gd.FailedFiles()); failed != 0 {
+		err := fmt.Errorf("%d of %d items failed", failed, len(files))
+		gd.listener.OnTransferError(gd, err)
+		return err
+	}
+	gd.listener.OnTransferComplete(gd, resultId)
+	return nil
+}
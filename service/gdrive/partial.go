@@ -0,0 +1,223 @@
+package gdrive
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/drive/v3"
+
+	"github.com/jaskaranSM/transfer-service/logging"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+)
+
+// partialMeta describes the source a partial file was downloaded from, it is
+// kept next to the partial file so a changed source restarts from byte 0.
+type partialMeta struct {
+	FileId       string `json:"file_id"`
+	Size         int64  `json:"size"`
+	Md5Checksum  string `json:"md5_checksum"`
+	ModifiedTime string `json:"modified_time"`
+	SegmentSize  int64  `json:"segment_size,omitempty"`
+	DoneSegments []bool `json:"done_segments,omitempty"`
+}
+
+func newPartialMeta(file *drive.File) *partialMeta {
+	return &partialMeta{
+		FileId:       file.Id,
+		Size:         file.Size,
+		Md5Checksum:  file.Md5Checksum,
+		ModifiedTime: file.ModifiedTime,
+	}
+}
+
+func (p *partialMeta) matches(other *partialMeta) bool {
+	if p.FileId != other.FileId || p.Size != other.Size || p.SegmentSize != other.SegmentSize {
+		return false
+	}
+	if p.Md5Checksum != "" && other.Md5Checksum != "" && p.Md5Checksum != other.Md5Checksum {
+		return false
+	}
+	if p.ModifiedTime != "" && other.ModifiedTime != "" && p.ModifiedTime != other.ModifiedTime {
+		return false
+	}
+	return true
+}
+
+func partialPath(path string) string {
+	return path + gdriveconstants.PartialFileSuffix
+}
+
+func partialMetaPath(path string) string {
+	return partialPath(path) + gdriveconstants.PartialMetaSuffix
+}
+
+func readPartialMeta(path string) *partialMeta {
+	b, err := os.ReadFile(partialMetaPath(path))
+	if err != nil {
+		return nil
+	}
+	meta := &partialMeta{}
+	err = json.Unmarshal(b, meta)
+	if err != nil {
+		return nil
+	}
+	return meta
+}
+
+func writePartialMeta(path string, meta *partialMeta) error {
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(partialMetaPath(path), b, 0644)
+}
+
+// openPartialFile opens the partial file for path and returns the offset the
+// download can continue from. Partial data left behind by a different source
+// is discarded.
+func (g *GoogleDriveFileTransfer) openPartialFile(file *drive.File, path string) (int64, error) {
+	logger := logging.GetLogger()
+	fileHandle, err := os.OpenFile(partialPath(path), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+	g.file = fileHandle
+	stat, err := fileHandle.Stat()
+	if err != nil {
+		return 0, err
+	}
+	offset := stat.Size()
+	meta := newPartialMeta(file)
+	old := readPartialMeta(path)
+	if offset > 0 && (old == nil || !old.matches(meta) || offset > file.Size) {
+		logger.Debug("Discarding partial file of a different source", zap.String("path", path), zap.Int64("offset", offset))
+		offset = 0
+	}
+	err = g.truncatePartial(offset)
+	if err != nil {
+		return 0, err
+	}
+	err = writePartialMeta(path, meta)
+	if err != nil {
+		return 0, err
+	}
+	return offset, nil
+}
+
+func (g *GoogleDriveFileTransfer) truncatePartial(offset int64) error {
+	err := g.file.Truncate(offset)
+	if err != nil {
+		return err
+	}
+	_, err = g.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+// finishPartialFile moves a completed partial file into place and stamps it
+// with the Drive modifiedTime so later syncs can compare them.
+func finishPartialFile(path string, file *drive.File) error {
+	err := os.Rename(partialPath(path), path)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(partialMetaPath(path))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	modifiedTime, err := time.Parse(time.RFC3339, file.ModifiedTime)
+	if err != nil {
+		return nil
+	}
+	return os.Chtimes(path, modifiedTime, modifiedTime)
+}
+
+func discardPartialFile(path string) {
+	for _, p := range []string{partialPath(path), partialMetaPath(path)} {
+		err := os.Remove(p)
+		if err != nil && !os.IsNotExist(err) {
+			logging.GetLogger().Error("Could not remove partial file", zap.Error(err), zap.String("path", p))
+		}
+	}
+}
+
+// openDownloadStream requests the file content starting at offset. The
+// request starts a little before offset and the overlap is compared with the
+// tail already on disk, a mismatch means the source changed underneath us and
+// the download restarts from byte 0.
+func (g *GoogleDriveFileTransfer) openDownloadStream(file *drive.File, offset int64) (io.ReadCloser, error) {
+	logger := logging.GetLogger()
+	tail := offset
+	if tail > gdriveconstants.PartialTailCheckSize {
+		tail = gdriveconstants.PartialTailCheckSize
+	}
+	if g.exportMime != "" {
+		// exports are generated on the fly and can not be ranged
+		res, err := g.service.Files.Export(file.Id, g.exportMime).Download()
+		if err != nil {
+			return nil, err
+		}
+		err = g.restartPartial()
+		if err != nil {
+			res.Body.Close()
+			return nil, err
+		}
+		return res.Body, nil
+	}
+	call := g.service.Files.Get(file.Id).SupportsAllDrives(true).SupportsTeamDrives(true).AcknowledgeAbuse(g.acknowledgeAbuse)
+	if offset > 0 {
+		call.Header().Set("Range", fmt.Sprintf("bytes=%d-", offset-tail))
+	}
+	res, err := call.Download()
+	if err != nil {
+		return nil, err
+	}
+	if offset == 0 {
+		return res.Body, nil
+	}
+	if res.StatusCode != http.StatusPartialContent {
+		logger.Debug("Range not honoured, restarting download", zap.String("fileID", file.Id), zap.Int("status", res.StatusCode))
+		err = g.restartPartial()
+		if err != nil {
+			res.Body.Close()
+			return nil, err
+		}
+		return res.Body, nil
+	}
+	remote := make([]byte, tail)
+	_, err = io.ReadFull(res.Body, remote)
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	local := make([]byte, tail)
+	_, err = g.file.ReadAt(local, offset-tail)
+	if err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+	if !bytes.Equal(local, remote) {
+		res.Body.Close()
+		logger.Debug("Partial file tail mismatch, restarting download", zap.String("fileID", file.Id), zap.Int64("offset", offset))
+		err = g.restartPartial()
+		if err != nil {
+			return nil, err
+		}
+		return g.openDownloadStream(file, 0)
+	}
+	return res.Body, nil
+}
+
+func (g *GoogleDriveFileTransfer) restartPartial() error {
+	err := g.truncatePartial(0)
+	if err != nil {
+		return err
+	}
+	g.syncProgress(0)
+	return nil
+}
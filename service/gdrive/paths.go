@@ -0,0 +1,166 @@
+package gdrive
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jaskaranSM/transfer-service/config"
+)
+
+// pathCache remembers resolved path components for PATH_CACHE_TTL seconds,
+// it is shared by all clients.
+type pathCache struct {
+	mut     sync.Mutex
+	entries map[string]*pathCacheEntry
+}
+
+type pathCacheEntry struct {
+	id      string
+	parent  string
+	expires time.Time
+}
+
+var drivePaths = &pathCache{
+	entries: make(map[string]*pathCacheEntry),
+}
+
+func (c *pathCache) get(key string) (string, bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return "", false
+	}
+	return entry.id, true
+}
+
+// put caches id under key, parent is the folder id the entry was looked up
+// in so changes to that folder forget it.
+func (c *pathCache) put(key string, parent string, id string) {
+	ttl := time.Duration(config.Get().PathCacheTTL) * time.Second
+	if ttl <= 0 {
+		return
+	}
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.entries[key] = &pathCacheEntry{
+		id:      id,
+		parent:  parent,
+		expires: time.Now().Add(ttl),
+	}
+}
+
+// forget drops the entries resolving to one of ids or looked up in one of
+// them.
+func (c *pathCache) forget(ids map[string]bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	for key, entry := range c.entries {
+		if ids[entry.id] || ids[entry.parent] {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// IsDrivePath reports whether ref is a path such as "team-drive:/assets/2026"
+// or "/assets", or a bare root such as "team-drive:", rather than a file id.
+func IsDrivePath(ref string) bool {
+	return strings.HasPrefix(ref, "/") || strings.Contains(ref, ":/") || strings.HasSuffix(ref, ":")
+}
+
+// ResolveId turns a Drive path into the id of the item it names, ids are
+// returned unchanged. The root before ":/" is an alias from PATH_ALIASES,
+// My Drive or the name of a shared drive, an empty root is My Drive.
+func (gd *GoogleDriveClient) ResolveId(ref string) (string, error) {
+	if !IsDrivePath(ref) {
+		return ref, nil
+	}
+	root, rest, found := strings.Cut(ref, ":/")
+	if !found && strings.HasSuffix(ref, ":") {
+		root, rest = strings.TrimSuffix(ref, ":"), ""
+	} else if !found {
+		root, rest = "", ref
+	}
+	id, err := gd.resolveRoot(root)
+	if err != nil {
+		return "", err
+	}
+	resolved := root + ":"
+	for _, name := range strings.Split(rest, "/") {
+		if name == "" || name == "." {
+			continue
+		}
+		resolved += "/" + name
+		id, err = gd.lookupChild(id, name, resolved)
+		if err != nil {
+			return "", err
+		}
+	}
+	return id, nil
+}
+
+// ResolveIds resolves every ref in place.
+func (gd *GoogleDriveClient) ResolveIds(refs ...*string) error {
+	for _, ref := range refs {
+		id, err := gd.ResolveId(*ref)
+		if err != nil {
+			return err
+		}
+		*ref = id
+	}
+	return nil
+}
+
+func (gd *GoogleDriveClient) resolveRoot(root string) (string, error) {
+	if id, ok := parseTypeList(config.Get().PathAliases)[root]; ok && id != "" {
+		return id, nil
+	}
+	switch strings.ToLower(root) {
+	case "", "root", "my drive", "mydrive":
+		return "root", nil
+	}
+	key := "drive:" + root
+	if id, ok := drivePaths.get(key); ok {
+		return id, nil
+	}
+	res, err := gd.DriveSrv.Drives.List().Q(fmt.Sprintf("name = '%s'", escapeQuery(root))).Fields("drives(id, name)").PageSize(2).Do()
+	if err != nil {
+		return "", err
+	}
+	switch len(res.Drives) {
+	case 0:
+		return "", fmt.Errorf("%s: no alias or shared drive with this name", root)
+	case 1:
+		drivePaths.put(key, "", res.Drives[0].Id)
+		return res.Drives[0].Id, nil
+	}
+	return "", fmt.Errorf("%s: ambiguous, more than one shared drive with this name", root)
+}
+
+// lookupChild finds the single non trashed item called name in parentId,
+// path is only used in errors.
+func (gd *GoogleDriveClient) lookupChild(parentId string, name string, path string) (string, error) {
+	key := parentId + "/" + name
+	if id, ok := drivePaths.get(key); ok {
+		return id, nil
+	}
+	query := fmt.Sprintf("'%s' in parents and name = '%s' and trashed = false", escapeQuery(parentId), escapeQuery(name))
+	files, err := gd.listFilesWithFields(query, 2, "nextPageToken,files(id)")
+	if err != nil {
+		return "", err
+	}
+	switch len(files) {
+	case 0:
+		return "", fmt.Errorf("%s: not found", path)
+	case 1:
+		drivePaths.put(key, parentId, files[0].Id)
+		return files[0].Id, nil
+	}
+	return "", fmt.Errorf("%s: ambiguous, more than one item with this name", path)
+}
@@ -0,0 +1,256 @@
+package gdrive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/jaskaranSM/transfer-service/config"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+)
+
+// PlanEntry is one step a dry run decided on. Path is below the destination
+// root for Drive destinations and the local path for downloads.
+type PlanEntry struct {
+	Action   string `json:"action"`
+	Path     string `json:"path"`
+	Size     int64  `json:"size,omitempty"`
+	Source   string `json:"source,omitempty"`
+	TargetId string `json:"target_id,omitempty"`
+}
+
+type PlanSummary struct {
+	FoldersToCreate   int   `json:"folders_to_create"`
+	FoldersToReuse    int   `json:"folders_to_reuse"`
+	FilesToCopy       int   `json:"files_to_copy"`
+	FilesToRename     int   `json:"files_to_rename"`
+	FilesToOverwrite  int   `json:"files_to_overwrite"`
+	FilesToSkip       int   `json:"files_to_skip"`
+	FilesToDelete     int   `json:"files_to_delete"`
+	Conflicts         int   `json:"conflicts"`
+	TotalBytes        int64 `json:"total_bytes"`
+	EstimatedApiCalls int64 `json:"estimated_api_calls"`
+}
+
+// Plan collects what a dry run would do. Folders it would create get
+// placeholder ids so the walk can continue below them.
+type Plan struct {
+	mut     sync.Mutex
+	summary PlanSummary
+	entries []*PlanEntry
+	dirs    map[string]string
+	folders int
+}
+
+func newPlan() *Plan {
+	return &Plan{
+		dirs: make(map[string]string),
+	}
+}
+
+// SetDryRun makes the client walk and plan without transferring, creating,
+// trashing or removing anything.
+func (gd *GoogleDriveClient) SetDryRun(dryRun bool) {
+	if dryRun {
+		gd.plan = newPlan()
+	} else {
+		gd.plan = nil
+	}
+}
+
+// Plan returns the plan of a dry run, nil when the client is not in one.
+func (gd *GoogleDriveClient) Plan() *Plan {
+	return gd.plan
+}
+
+func (p *Plan) Summary() PlanSummary {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return p.summary
+}
+
+func (p *Plan) Len() int {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return len(p.entries)
+}
+
+// Entries returns up to limit entries starting at offset, limit <= 0 returns
+// everything after offset.
+func (p *Plan) Entries(offset int, limit int) []*PlanEntry {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	if offset < 0 || offset >= len(p.entries) {
+		return []*PlanEntry{}
+	}
+	end := len(p.entries)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	return append([]*PlanEntry{}, p.entries[offset:end]...)
+}
+
+func (p *Plan) add(entry *PlanEntry, calls int64) {
+	if p == nil {
+		return
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.entries = append(p.entries, entry)
+	p.summary.EstimatedApiCalls += calls
+	switch entry.Action {
+	case gdriveconstants.PlanCreateFolder:
+		p.summary.FoldersToCreate += 1
+	case gdriveconstants.PlanReuseFolder:
+		p.summary.FoldersToReuse += 1
+	case gdriveconstants.PlanCopy:
+		p.summary.FilesToCopy += 1
+		p.summary.TotalBytes += entry.Size
+	case gdriveconstants.PlanRename:
+		p.summary.FilesToRename += 1
+		p.summary.TotalBytes += entry.Size
+	case gdriveconstants.PlanOverwrite:
+		p.summary.FilesToOverwrite += 1
+		p.summary.TotalBytes += entry.Size
+	case gdriveconstants.PlanSkip:
+		p.summary.FilesToSkip += 1
+	case gdriveconstants.PlanDelete:
+		p.summary.FilesToDelete += 1
+	case gdriveconstants.PlanConflict:
+		p.summary.Conflicts += 1
+	}
+}
+
+// countCall counts a request the dry run really made, the same listings
+// happen during the transfer.
+func (p *Plan) countCall() {
+	if p == nil {
+		return
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.summary.EstimatedApiCalls += 1
+}
+
+// destPath returns the path of name inside the Drive folder parentId.
+func (p *Plan) destPath(parentId string, name string) string {
+	if p == nil {
+		return ""
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return joinRel(p.dirs[parentId], name)
+}
+
+// addFolder records a folder the walk descends into, a created folder gets a
+// placeholder id.
+func (p *Plan) addFolder(action string, dirId string, parentId string, name string) string {
+	p.mut.Lock()
+	if action == gdriveconstants.PlanCreateFolder {
+		p.folders += 1
+		dirId = fmt.Sprintf("%s%d", gdriveconstants.PlanFolderPrefix, p.folders)
+	}
+	path := joinRel(p.dirs[parentId], name)
+	p.dirs[dirId] = path
+	p.mut.Unlock()
+	entry := &PlanEntry{
+		Action: action,
+		Path:   path,
+	}
+	var calls int64
+	if action == gdriveconstants.PlanCreateFolder {
+		calls = 1
+	} else {
+		entry.TargetId = dirId
+	}
+	p.add(entry, calls)
+	return dirId
+}
+
+func isPlannedFolder(id string) bool {
+	return strings.HasPrefix(id, gdriveconstants.PlanFolderPrefix)
+}
+
+func uploadCalls(size int64) int64 {
+	chunks := (size + gdriveconstants.UploadChunkSize - 1) / gdriveconstants.UploadChunkSize
+	if chunks == 0 {
+		chunks = 1
+	}
+	// one request opens the session
+	return chunks + 1
+}
+
+// planUpload records the upload of the local file at path under name, an
+// empty name keeps the local file name.
+func (gd *GoogleDriveClient) planUpload(action string, path string, name string, parentId string, targetId string) error {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		name = filepath.Base(path)
+	}
+	gd.plan.add(&PlanEntry{
+		Action:   action,
+		Path:     gd.plan.destPath(parentId, name),
+		Size:     stat.Size(),
+		Source:   path,
+		TargetId: targetId,
+	}, uploadCalls(stat.Size()))
+	return nil
+}
+
+// planClone records a server side copy of file, overwriting targetId takes
+// a second request to trash it.
+func (gd *GoogleDriveClient) planClone(action string, file *drive.File, name string, desId string, targetId string) {
+	if name == "" {
+		name = file.Name
+	}
+	var calls int64 = 1
+	if targetId != "" {
+		calls += 1
+	}
+	gd.plan.add(&PlanEntry{
+		Action:   action,
+		Path:     gd.plan.destPath(desId, name),
+		Size:     file.Size,
+		Source:   file.Id,
+		TargetId: targetId,
+	}, calls)
+}
+
+func (gd *GoogleDriveClient) planDownload(file *drive.File, localPath string) {
+	action := gdriveconstants.PlanCopy
+	if filepath.Base(localPath) != gd.localFileName(file) {
+		action = gdriveconstants.PlanRename
+	} else if _, err := os.Stat(localPath); err == nil {
+		action = gdriveconstants.PlanOverwrite
+	}
+	var calls int64 = 1
+	if shouldSegment(file, cap(gd.concurrency)) {
+		calls = int64(len(splitSegments(file.Size, config.Get().SegmentSize)))
+	}
+	gd.plan.add(&PlanEntry{
+		Action: action,
+		Path:   localPath,
+		Size:   file.Size,
+		Source: file.Id,
+	}, calls)
+}
+
+// mkdirLocal creates the local directory path, a dry run only records it.
+func (gd *GoogleDriveClient) mkdirLocal(path string) error {
+	if gd.plan == nil {
+		return os.MkdirAll(path, 0755)
+	}
+	if _, err := os.Stat(path); err == nil {
+		gd.plan.add(&PlanEntry{Action: gdriveconstants.PlanReuseFolder, Path: path}, 0)
+		return nil
+	}
+	gd.plan.add(&PlanEntry{Action: gdriveconstants.PlanCreateFolder, Path: path}, 0)
+	return nil
+}
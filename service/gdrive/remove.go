@@ -0,0 +1,122 @@
+package gdrive
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/drive/v3"
+
+	"github.com/jaskaranSM/transfer-service/logging"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+	"github.com/jaskaranSM/transfer-service/utils"
+)
+
+var removeVerbs = map[string]string{
+	gdriveconstants.TransferTypeTrashing:   "trashing",
+	gdriveconstants.TransferTypeUntrashing: "untrashing",
+	gdriveconstants.TransferTypeDeleting:   "deleting",
+}
+
+func (gd *GoogleDriveClient) UntrashFile(fileId string) error {
+	file := &drive.File{
+		Trashed:         false,
+		ForceSendFields: []string{"Trashed"},
+	}
+	_, err := gd.DriveSrv.Files.Update(fileId, file).SupportsAllDrives(true).Do()
+	invalidateMetadata(fileId)
+	if err != nil {
+		logging.GetLogger().Error("Could not untrash file", zap.Error(err), zap.String("fileID", fileId))
+		return err
+	}
+	return nil
+}
+
+// DeleteFile removes fileId for good, skipping the trash. Deleting a folder
+// deletes everything below it.
+func (gd *GoogleDriveClient) DeleteFile(fileId string) error {
+	err := gd.DriveSrv.Files.Delete(fileId).SupportsAllDrives(true).Do()
+	invalidateMetadata(fileId)
+	if err != nil {
+		logging.GetLogger().Error("Could not delete file", zap.Error(err), zap.String("fileID", fileId))
+		return err
+	}
+	return nil
+}
+
+func (gd *GoogleDriveClient) isEmptyFolder(folderId string) (bool, error) {
+	files, err := gd.listFilesWithFields(fmt.Sprintf("'%s' in parents and trashed = false", escapeQuery(folderId)), 1, "nextPageToken,files(id)")
+	if err != nil {
+		return false, err
+	}
+	return len(files) == 0, nil
+}
+
+// untrashBelow restores the trashed entries below folderId. Entries trashed
+// on their own are not restored together with their parent.
+func (gd *GoogleDriveClient) untrashBelow(folderId string) error {
+	q := utils.NewQueue()
+	q.Enqueue(utils.NewDirValue(folderId, ""))
+	for !q.IsEmpty() {
+		dirItem := q.Deque()
+		files, err := gd.listFilesWithFields(fmt.Sprintf("'%s' in parents", escapeQuery(dirItem.Src)), -1, "nextPageToken,files(id, name, mimeType, trashed)")
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			if file.Trashed {
+				err = gd.UntrashFile(file.Id)
+				if err != nil {
+					return err
+				}
+			}
+			if gd.IsDir(file) {
+				q.Enqueue(utils.NewDirValue(file.Id, ""))
+			}
+		}
+	}
+	return nil
+}
+
+// removeItem trashes, untrashes or deletes one item. Without recursive a
+// non empty folder is refused for trash and delete, and only the folder
+// itself is restored on untrash.
+func (gd *GoogleDriveClient) removeItem(op string, file *drive.File, recursive bool) error {
+	if op != gdriveconstants.TransferTypeUntrashing && gd.IsDir(file) && !recursive {
+		empty, err := gd.isEmptyFolder(file.Id)
+		if err != nil {
+			return err
+		}
+		if !empty {
+			return fmt.Errorf("%s is a non empty folder, set recursive to %s it", file.Name, op)
+		}
+	}
+	switch op {
+	case gdriveconstants.TransferTypeTrashing:
+		return gd.TrashFile(file.Id)
+	case gdriveconstants.TransferTypeUntrashing:
+		err := gd.UntrashFile(file.Id)
+		if err != nil || !recursive || !gd.IsDir(file) {
+			return err
+		}
+		return gd.untrashBelow(file.Id)
+	case gdriveconstants.TransferTypeDeleting:
+		return gd.DeleteFile(file.Id)
+	}
+	return fmt.Errorf("unknown operation: %s", op)
+}
+
+// RemoveFiles applies op, one of trash, untrash or delete, to files and
+// reports every item.
+func (gd *GoogleDriveClient) RemoveFiles(op string, files []*drive.File, recursive bool) []*ItemResult {
+	return gd.runItems(files, func(file *drive.File) error {
+		return gd.removeItem(op, file, recursive)
+	})
+}
+
+// Remove runs RemoveFiles as a tracked job.
+func (gd *GoogleDriveClient) Remove(op string, files []*drive.File, recursive bool) error {
+	logging.GetLogger().Info("starting "+op, zap.Int("items", len(files)), zap.Bool("recursive", recursive))
+	return gd.runItemsJob(removeVerbs[op], files, "", func(file *drive.File) error {
+		return gd.removeItem(op, file, recursive)
+	})
+}
@@ -0,0 +1,285 @@
+package gdrive
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+
+	"github.com/jaskaranSM/transfer-service/config"
+	"github.com/jaskaranSM/transfer-service/constants"
+	"github.com/jaskaranSM/transfer-service/logging"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+	"github.com/jaskaranSM/transfer-service/utils"
+)
+
+var errUploadSessionExpired = errors.New("upload session expired")
+
+// Drive keeps resumable sessions for a week, stop trusting ours a bit earlier.
+const uploadSessionMaxAge = 6 * 24 * time.Hour
+
+type uploadSession struct {
+	URI       string    `json:"uri"`
+	Path      string    `json:"path"`
+	ParentId  string    `json:"parent_id"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func uploadSessionKey(path string, parentId string, updateFileId string, stat os.FileInfo) string {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%s|%s|%d|%d", absPath, parentId, updateFileId, stat.Size(), stat.ModTime().UnixNano())))
+	return hex.EncodeToString(sum[:])
+}
+
+func uploadSessionPath(key string) string {
+	return filepath.Join(config.Get().StateDir, gdriveconstants.UploadSessionsDir, key+".json")
+}
+
+func loadUploadSession(key string) *uploadSession {
+	logger := logging.GetLogger()
+	b, err := os.ReadFile(uploadSessionPath(key))
+	if err != nil {
+		return nil
+	}
+	session := &uploadSession{}
+	err = json.Unmarshal(b, session)
+	if err != nil {
+		logger.Error("Could not decode upload session", zap.Error(err), zap.String("key", key))
+		return nil
+	}
+	if time.Since(session.CreatedAt) > uploadSessionMaxAge {
+		logger.Debug("Dropping stale upload session", zap.String("key", key))
+		removeUploadSession(key)
+		return nil
+	}
+	return session
+}
+
+func saveUploadSession(key string, session *uploadSession) {
+	logger := logging.GetLogger()
+	sessionPath := uploadSessionPath(key)
+	err := os.MkdirAll(filepath.Dir(sessionPath), 0755)
+	if err != nil {
+		logger.Error("Could not create upload sessions dir", zap.Error(err), zap.String("path", sessionPath))
+		return
+	}
+	b, err := json.Marshal(session)
+	if err != nil {
+		logger.Error("Could not encode upload session", zap.Error(err))
+		return
+	}
+	err = os.WriteFile(sessionPath, b, 0600)
+	if err != nil {
+		logger.Error("Could not save upload session", zap.Error(err), zap.String("path", sessionPath))
+	}
+}
+
+func removeUploadSession(key string) {
+	err := os.Remove(uploadSessionPath(key))
+	if err != nil && !os.IsNotExist(err) {
+		logging.GetLogger().Error("Could not remove upload session", zap.Error(err), zap.String("key", key))
+	}
+}
+
+// resumableUpload uploads g.file through a Drive resumable session. The
+// session URI is persisted under the state dir so a failed or interrupted
+// upload continues from the committed offset instead of byte 0.
+func (g *GoogleDriveFileTransfer) resumableUpload(path string, parentId string, stat os.FileInfo) (*drive.File, error) {
+	logger := logging.GetLogger()
+	key := uploadSessionKey(path, parentId, g.updateFileId, stat)
+	size := stat.Size()
+	var offset int64
+	session := loadUploadSession(key)
+	if session != nil {
+		var file *drive.File
+		var err error
+		offset, file, err = g.queryUploadOffset(session.URI, size)
+		if err == errUploadSessionExpired {
+			logger.Debug("Upload session expired, starting over", zap.String("path", path))
+			removeUploadSession(key)
+			session = nil
+			offset = 0
+		} else if err != nil {
+			return nil, err
+		} else if file != nil {
+			return file, nil
+		}
+	}
+	if session == nil {
+		name := g.targetName
+		if name == "" {
+			name = filepath.Base(path)
+		}
+		f := &drive.File{
+			MimeType:     utils.GetFileContentTypePath(path),
+			Name:         name,
+			ModifiedTime: stat.ModTime().UTC().Format(time.RFC3339Nano),
+		}
+		if g.updateFileId == "" {
+			f.Parents = []string{parentId}
+		}
+		uri, err := g.createUploadSession(f, size)
+		if err != nil {
+			return nil, err
+		}
+		session = &uploadSession{
+			URI:       uri,
+			Path:      path,
+			ParentId:  parentId,
+			Size:      size,
+			CreatedAt: time.Now(),
+		}
+		saveUploadSession(key, session)
+		if size == 0 {
+			_, file, err := g.queryUploadOffset(session.URI, size)
+			return file, err
+		}
+	}
+	for {
+		g.syncProgress(offset)
+		_, err := g.file.Seek(offset, io.SeekStart)
+		if err != nil {
+			return nil, err
+		}
+		if g.verify && (g.hash == nil || g.hashed != offset) {
+			err = g.resetHash(offset)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// offset is committed by Drive, a later short commit restarts here
+		err = g.checkpointHash(offset)
+		if err != nil {
+			return nil, err
+		}
+		length := size - offset
+		if length > gdriveconstants.UploadChunkSize {
+			length = gdriveconstants.UploadChunkSize
+		}
+		var file *drive.File
+		offset, file, err = g.uploadChunk(session.URI, offset, length, size)
+		if err != nil {
+			return nil, err
+		}
+		if file != nil {
+			return file, nil
+		}
+	}
+}
+
+// createUploadSession starts a resumable session, a new file is created in
+// the parents of f unless the transfer updates an existing file in place.
+func (g *GoogleDriveFileTransfer) createUploadSession(f *drive.File, size int64) (string, error) {
+	body, err := json.Marshal(f)
+	if err != nil {
+		return "", err
+	}
+	params := url.Values{}
+	params.Set("uploadType", "resumable")
+	params.Set("supportsAllDrives", "true")
+	params.Set("fields", "id,name,mimeType,size,md5Checksum,modifiedTime")
+	method := http.MethodPost
+	uploadURL := gdriveconstants.UploadURL
+	if g.updateFileId != "" {
+		method = http.MethodPatch
+		uploadURL += "/" + url.PathEscape(g.updateFileId)
+	}
+	req, err := http.NewRequest(method, uploadURL+"?"+params.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Upload-Content-Type", f.MimeType)
+	req.Header.Set("X-Upload-Content-Length", strconv.FormatInt(size, 10))
+	res, err := g.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	err = googleapi.CheckResponse(res)
+	if err != nil {
+		return "", err
+	}
+	uri := res.Header.Get("Location")
+	if uri == "" {
+		return "", errors.New("resumable upload: no session uri in response")
+	}
+	return uri, nil
+}
+
+// queryUploadOffset asks Drive how many bytes of the session it has
+// committed. A non-nil file means the upload had already finished.
+func (g *GoogleDriveFileTransfer) queryUploadOffset(uri string, size int64) (int64, *drive.File, error) {
+	req, err := http.NewRequest(http.MethodPut, uri, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+	return g.doUploadRequest(req)
+}
+
+func (g *GoogleDriveFileTransfer) uploadChunk(uri string, offset int64, length int64, size int64) (int64, *drive.File, error) {
+	req, err := http.NewRequest(http.MethodPut, uri, io.LimitReader(g, length))
+	if err != nil {
+		return offset, nil, err
+	}
+	req.ContentLength = length
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, size))
+	return g.doUploadRequest(req)
+}
+
+func (g *GoogleDriveFileTransfer) doUploadRequest(req *http.Request) (int64, *drive.File, error) {
+	res, err := g.httpClient.Do(req)
+	if err != nil {
+		if g.isCancelled {
+			return 0, nil, constants.CancelledByUserError
+		}
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusPermanentRedirect:
+		return committedOffset(res.Header.Get("Range")), nil, nil
+	case http.StatusOK, http.StatusCreated:
+		file := &drive.File{}
+		err = json.NewDecoder(res.Body).Decode(file)
+		if err != nil {
+			return 0, nil, err
+		}
+		return 0, file, nil
+	case http.StatusNotFound, http.StatusGone:
+		return 0, nil, errUploadSessionExpired
+	}
+	return 0, nil, googleapi.CheckResponse(res)
+}
+
+// committedOffset parses a "bytes=0-N" Range header into the next offset.
+func committedOffset(rangeHeader string) int64 {
+	i := strings.LastIndex(rangeHeader, "-")
+	if i == -1 {
+		return 0
+	}
+	last, err := strconv.ParseInt(rangeHeader[i+1:], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return last + 1
+}
@@ -0,0 +1,25 @@
+package gdrive
+
+import (
+	"testing"
+)
+
+func TestCommittedOffset(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int64
+	}{
+		{"", 0},
+		{"bytes=0-0", 1},
+		{"bytes=0-262143", 262144},
+		{"bytes=0-", 0},
+		{"bytes=0-abc", 0},
+		{"garbage", 0},
+	}
+	for _, test := range tests {
+		got := committedOffset(test.header)
+		if got != test.want {
+			t.Errorf("committedOffset(%q) = %d, want %d", test.header, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package gdrive
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+const retryBaseDelay = time.Second
+const retryMaxDelay = 32 * time.Second
+
+var rateLimitReasons = map[string]bool{
+	"rateLimitExceeded":        true,
+	"userRateLimitExceeded":    true,
+	"sharingRateLimitExceeded": true,
+}
+
+// isRetryable reports whether a failed Drive call may succeed when repeated,
+// which holds for server errors, 429 and 403 rate limits. Errors that are
+// not API errors, such as dropped connections, are retried as well.
+func isRetryable(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	if apiErr.Code >= 500 || apiErr.Code == http.StatusTooManyRequests {
+		return true
+	}
+	if apiErr.Code == http.StatusForbidden {
+		for _, item := range apiErr.Errors {
+			if rateLimitReasons[item.Reason] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// retryDelay is the exponential backoff with jitter before retry number
+// retry+1.
+func retryDelay(retry int) time.Duration {
+	delay := retryBaseDelay << retry
+	if delay > retryMaxDelay || delay <= 0 {
+		delay = retryMaxDelay
+	}
+	return delay + time.Duration(rand.Int63n(int64(retryBaseDelay)))
+}
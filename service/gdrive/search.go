@@ -0,0 +1,154 @@
+package gdrive
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+
+	"github.com/jaskaranSM/transfer-service/logging"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+	"github.com/jaskaranSM/transfer-service/types"
+)
+
+const searchFields = "nextPageToken,incompleteSearch,files(id,name,mimeType,size,md5Checksum,modifiedTime,parents,driveId,trashed,owners(emailAddress))"
+
+type SearchResult struct {
+	Files            []*drive.File `json:"files"`
+	NextPageToken    string        `json:"next_page_token,omitempty"`
+	IncompleteSearch bool          `json:"incomplete_search"`
+}
+
+// escapeQuery escapes a value for use inside a quoted Drive query string.
+func escapeQuery(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return strings.ReplaceAll(value, `'`, `\'`)
+}
+
+// searchTime checks an RFC3339 bound and formats it the way Drive queries
+// expect it.
+func searchTime(value string) (string, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", fmt.Errorf("invalid time %q: %w", value, err)
+	}
+	return t.UTC().Format("2006-01-02T15:04:05"), nil
+}
+
+// CompileSearch turns the filter of request into a Drive query, every value
+// is escaped. Sizes cannot be queried and are checked by Search.
+func CompileSearch(request *types.SearchRequest) (string, error) {
+	var terms []string
+	if request.NameContains != "" {
+		terms = append(terms, fmt.Sprintf("name contains '%s'", escapeQuery(request.NameContains)))
+	}
+	if request.NameEquals != "" {
+		terms = append(terms, fmt.Sprintf("name = '%s'", escapeQuery(request.NameEquals)))
+	}
+	if len(request.MimeTypes) != 0 {
+		var mimeTypes []string
+		for _, mimeType := range request.MimeTypes {
+			mimeTypes = append(mimeTypes, fmt.Sprintf("mimeType = '%s'", escapeQuery(mimeType)))
+		}
+		terms = append(terms, "("+strings.Join(mimeTypes, " or ")+")")
+	}
+	if request.ModifiedAfter != "" {
+		after, err := searchTime(request.ModifiedAfter)
+		if err != nil {
+			return "", err
+		}
+		terms = append(terms, fmt.Sprintf("modifiedTime > '%s'", after))
+	}
+	if request.ModifiedBefore != "" {
+		before, err := searchTime(request.ModifiedBefore)
+		if err != nil {
+			return "", err
+		}
+		terms = append(terms, fmt.Sprintf("modifiedTime < '%s'", before))
+	}
+	if request.MinSize < 0 || request.MaxSize < 0 || (request.MaxSize != 0 && request.MinSize > request.MaxSize) {
+		return "", fmt.Errorf("invalid size range: %d to %d", request.MinSize, request.MaxSize)
+	}
+	switch request.Trashed {
+	case "", gdriveconstants.SearchTrashedExclude:
+		terms = append(terms, "trashed = false")
+	case gdriveconstants.SearchTrashedOnly:
+		terms = append(terms, "trashed = true")
+	case gdriveconstants.SearchTrashedInclude:
+	default:
+		return "", fmt.Errorf("unknown trashed option: %s", request.Trashed)
+	}
+	if request.Owner != "" {
+		terms = append(terms, fmt.Sprintf("'%s' in owners", escapeQuery(request.Owner)))
+	}
+	if request.ParentId != "" {
+		terms = append(terms, fmt.Sprintf("'%s' in parents", escapeQuery(request.ParentId)))
+	}
+	return strings.Join(terms, " and "), nil
+}
+
+func matchSearchSize(request *types.SearchRequest, file *drive.File) bool {
+	if request.MinSize == 0 && request.MaxSize == 0 {
+		return true
+	}
+	if file.MimeType == "application/vnd.google-apps.folder" {
+		return false
+	}
+	if file.Size < request.MinSize {
+		return false
+	}
+	return request.MaxSize == 0 || file.Size <= request.MaxSize
+}
+
+// Search returns one page of files matching request. A size range is
+// applied to each page after listing, so pages may come back short while
+// NextPageToken is still set.
+func (gd *GoogleDriveClient) Search(request *types.SearchRequest) (*SearchResult, error) {
+	logger := logging.GetLogger()
+	query, err := CompileSearch(request)
+	if err != nil {
+		return nil, err
+	}
+	pageSize := request.PageSize
+	if pageSize <= 0 {
+		pageSize = gdriveconstants.SearchPageSize
+	}
+	if pageSize > gdriveconstants.SearchMaxPageSize {
+		pageSize = gdriveconstants.SearchMaxPageSize
+	}
+	call := gd.DriveSrv.Files.List().Q(query).SupportsAllDrives(true).IncludeItemsFromAllDrives(true).
+		PageSize(int64(pageSize)).Fields(googleapi.Field(searchFields))
+	corpora := request.Corpora
+	if request.DriveId != "" {
+		if corpora == "" {
+			corpora = "drive"
+		}
+		call = call.DriveId(request.DriveId)
+	}
+	if corpora != "" {
+		call = call.Corpora(corpora)
+	}
+	if request.PageToken != "" {
+		call = call.PageToken(request.PageToken)
+	}
+	logger.Debug("Searching files", zap.String("query", query), zap.String("corpora", corpora))
+	res, err := call.Do()
+	if err != nil {
+		logger.Error("Search failed", zap.Error(err), zap.String("query", query))
+		return nil, err
+	}
+	result := &SearchResult{
+		Files:            []*drive.File{},
+		NextPageToken:    res.NextPageToken,
+		IncompleteSearch: res.IncompleteSearch,
+	}
+	for _, file := range res.Files {
+		if matchSearchSize(request, file) {
+			result.Files = append(result.Files, file)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,125 @@
+package gdrive
+
+import (
+	"testing"
+
+	"github.com/jaskaranSM/transfer-service/types"
+)
+
+func TestEscapeQuery(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{`plain`, `plain`},
+		{`it's`, `it\'s`},
+		{`back\slash`, `back\\slash`},
+		{`\'`, `\\\'`},
+		{`''`, `\'\'`},
+	}
+	for _, test := range tests {
+		got := escapeQuery(test.value)
+		if got != test.want {
+			t.Errorf("escapeQuery(%q) = %q, want %q", test.value, got, test.want)
+		}
+	}
+}
+
+func TestCompileSearch(t *testing.T) {
+	tests := []struct {
+		name    string
+		request types.SearchRequest
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "empty excludes trashed",
+			want: "trashed = false",
+		},
+		{
+			name:    "name contains with quote",
+			request: types.SearchRequest{NameContains: "bob's"},
+			want:    `name contains 'bob\'s' and trashed = false`,
+		},
+		{
+			name:    "name equals with backslash and quote",
+			request: types.SearchRequest{NameEquals: `a\' or name != '`},
+			want:    `name = 'a\\\' or name != \'' and trashed = false`,
+		},
+		{
+			name:    "mime types are or'd",
+			request: types.SearchRequest{MimeTypes: []string{"image/png", "image/jpeg"}},
+			want:    "(mimeType = 'image/png' or mimeType = 'image/jpeg') and trashed = false",
+		},
+		{
+			name:    "trashed exclude",
+			request: types.SearchRequest{Trashed: "exclude"},
+			want:    "trashed = false",
+		},
+		{
+			name:    "trashed only",
+			request: types.SearchRequest{Trashed: "only"},
+			want:    "trashed = true",
+		},
+		{
+			name:    "trashed include",
+			request: types.SearchRequest{Trashed: "include", Owner: "a@b.c"},
+			want:    "'a@b.c' in owners",
+		},
+		{
+			name:    "unknown trashed mode",
+			request: types.SearchRequest{Trashed: "maybe"},
+			wantErr: true,
+		},
+		{
+			name: "time bounds are converted to UTC",
+			request: types.SearchRequest{
+				ModifiedAfter:  "2026-01-02T03:04:05+02:00",
+				ModifiedBefore: "2026-02-01T00:00:00Z",
+			},
+			want: "modifiedTime > '2026-01-02T01:04:05' and modifiedTime < '2026-02-01T00:00:00' and trashed = false",
+		},
+		{
+			name:    "bad time bound",
+			request: types.SearchRequest{ModifiedAfter: "yesterday"},
+			wantErr: true,
+		},
+		{
+			name:    "size range is not part of the query",
+			request: types.SearchRequest{MinSize: 10, MaxSize: 20},
+			want:    "trashed = false",
+		},
+		{
+			name:    "min size above max size",
+			request: types.SearchRequest{MinSize: 20, MaxSize: 10},
+			wantErr: true,
+		},
+		{
+			name:    "negative size",
+			request: types.SearchRequest{MinSize: -1},
+			wantErr: true,
+		},
+		{
+			name:    "parent is escaped",
+			request: types.SearchRequest{ParentId: "id'x"},
+			want:    `trashed = false and 'id\'x' in parents`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := CompileSearch(&test.request)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("CompileSearch() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CompileSearch() error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("CompileSearch() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package gdrive
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/drive/v3"
+
+	"github.com/jaskaranSM/transfer-service/config"
+	"github.com/jaskaranSM/transfer-service/constants"
+	"github.com/jaskaranSM/transfer-service/logging"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+)
+
+type segment struct {
+	index int
+	start int64
+	end   int64
+}
+
+// segmentWriter writes a byte range of the partial file, several of them
+// write into the same file handle concurrently.
+type segmentWriter struct {
+	transfer *GoogleDriveFileTransfer
+	offset   int64
+	written  int64
+}
+
+func (w *segmentWriter) Write(p []byte) (int, error) {
+	g := w.transfer
+	if g.isCancelled {
+		return 0, constants.CancelledByUserError
+	}
+	bytesWritten, err := g.file.WriteAt(p, w.offset)
+	w.offset += int64(bytesWritten)
+	w.written += int64(bytesWritten)
+	g.addProgress(int64(bytesWritten))
+	return bytesWritten, err
+}
+
+func shouldSegment(file *drive.File, slots int) bool {
+	cfg := config.Get()
+	return slots > 1 && cfg.SegmentSize > 0 && cfg.SegmentThreshold > 0 && file.Size >= cfg.SegmentThreshold
+}
+
+func splitSegments(size int64, segmentSize int64) []*segment {
+	var segments []*segment
+	for start := int64(0); start < size; start += segmentSize {
+		end := start + segmentSize - 1
+		if end >= size {
+			end = size - 1
+		}
+		segments = append(segments, &segment{
+			index: len(segments),
+			start: start,
+			end:   end,
+		})
+	}
+	return segments
+}
+
+// openSegmentedFile preallocates the partial file and returns the segments
+// which still have to be fetched. Segments finished by an earlier attempt
+// are kept as long as the source did not change.
+func (g *GoogleDriveFileTransfer) openSegmentedFile(file *drive.File, path string) ([]*segment, *partialMeta, error) {
+	logger := logging.GetLogger()
+	segmentSize := config.Get().SegmentSize
+	fileHandle, err := os.OpenFile(partialPath(path), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	g.file = fileHandle
+	g.clearHash()
+	stat, err := fileHandle.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	segments := splitSegments(file.Size, segmentSize)
+	meta := newPartialMeta(file)
+	meta.SegmentSize = segmentSize
+	old := readPartialMeta(path)
+	if old != nil && old.matches(meta) && stat.Size() == file.Size && len(old.DoneSegments) == len(segments) {
+		meta.DoneSegments = old.DoneSegments
+	} else {
+		if stat.Size() > 0 {
+			logger.Debug("Discarding partial file of a different source", zap.String("path", path))
+		}
+		meta.DoneSegments = make([]bool, len(segments))
+		err = fileHandle.Truncate(0)
+		if err != nil {
+			return nil, nil, err
+		}
+		err = fileHandle.Truncate(file.Size)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	err = writePartialMeta(path, meta)
+	if err != nil {
+		return nil, nil, err
+	}
+	var pending []*segment
+	var done int64
+	for _, s := range segments {
+		if meta.DoneSegments[s.index] {
+			done += s.end - s.start + 1
+			continue
+		}
+		pending = append(pending, s)
+	}
+	g.syncProgress(done)
+	return pending, meta, nil
+}
+
+// SegmentedDownload fetches a large file as parallel byte ranges written at
+// their offsets. The caller holds one slot of slots for this transfer, every
+// further segment worker takes its own slot while it is fetching. Segments
+// arrive out of order, so verification hashes the finished file from disk.
+func (g *GoogleDriveFileTransfer) SegmentedDownload(file *drive.File, path string, slots chan int, retry int) {
+	logger := logging.GetLogger()
+	g.transferType = gdriveconstants.TransferTypeDownloading
+	g.name = path
+	if retry == 0 {
+		logger.Debug("on Transfer Start:", zap.String("fileID", file.Id))
+		g.listener.OnTransferStart(g)
+	}
+	pending, meta, err := g.openSegmentedFile(file, path)
+	if err != nil {
+		if g.file != nil {
+			g.file.Close()
+		}
+		g.err = err
+		logger.Error("Error while opening segmented file", zap.Error(err), zap.String("filepath", path))
+		g.listener.OnTransferError(g, err)
+		return
+	}
+	queue := make(chan *segment, len(pending))
+	for _, s := range pending {
+		queue <- s
+	}
+	close(queue)
+	var metaMut sync.Mutex
+	var segmentErr error
+	var wg sync.WaitGroup
+	for i := 0; i < cap(slots) && i < len(pending); i++ {
+		wg.Add(1)
+		go func(holdsSlot bool) {
+			defer wg.Done()
+			for s := range queue {
+				metaMut.Lock()
+				failed := segmentErr != nil
+				metaMut.Unlock()
+				if failed || g.isCancelled {
+					return
+				}
+				if !holdsSlot {
+					slots <- 1
+				}
+				err := g.downloadSegment(file, s, 0)
+				if !holdsSlot {
+					<-slots
+				}
+				metaMut.Lock()
+				if err != nil {
+					if segmentErr == nil {
+						segmentErr = err
+					}
+					metaMut.Unlock()
+					return
+				}
+				meta.DoneSegments[s.index] = true
+				err = writePartialMeta(path, meta)
+				metaMut.Unlock()
+				if err != nil {
+					logger.Error("Could not save segment state", zap.Error(err), zap.String("path", path))
+				}
+			}
+		}(i == 0)
+	}
+	wg.Wait()
+	if segmentErr == nil && g.isCancelled {
+		segmentErr = constants.CancelledByUserError
+	}
+	if segmentErr == nil {
+		err = g.checkChecksum(path, file.Md5Checksum, file.Size)
+		if err != nil {
+			g.file.Close()
+			discardPartialFile(path)
+			if retry < gdriveconstants.MaxRetries {
+				logger.Debug("Retrying segmented download after checksum mismatch", zap.String("path", path), zap.Int("retry", retry), zap.Error(err))
+				g.SegmentedDownload(file, path, slots, retry+1)
+				return
+			}
+			segmentErr = err
+		}
+	}
+	g.file.Close()
+	if segmentErr == nil {
+		segmentErr = finishPartialFile(path, file)
+	}
+	if segmentErr != nil {
+		g.err = segmentErr
+		logger.Error("Error while downloading segmented file", zap.Error(segmentErr), zap.String("path", path))
+		g.listener.OnTransferError(g, segmentErr)
+		return
+	}
+	g.isCompleted = true
+	logger.Debug("on transfer complete", zap.String("path", path))
+	g.listener.OnTransferComplete(g)
+}
+
+func (g *GoogleDriveFileTransfer) downloadSegment(file *drive.File, s *segment, retry int) error {
+	logger := logging.GetLogger()
+	writer := &segmentWriter{
+		transfer: g,
+		offset:   s.start,
+	}
+	call := g.service.Files.Get(file.Id).SupportsAllDrives(true).SupportsTeamDrives(true).AcknowledgeAbuse(g.acknowledgeAbuse)
+	call.Header().Set("Range", fmt.Sprintf("bytes=%d-%d", s.start, s.end))
+	res, err := call.Download()
+	if err == nil {
+		if res.StatusCode != http.StatusPartialContent {
+			err = fmt.Errorf("segment %d: expected partial content, got status %d", s.index, res.StatusCode)
+		} else {
+			_, err = io.Copy(writer, io.LimitReader(res.Body, s.end-s.start+1))
+			if err == nil && writer.written != s.end-s.start+1 {
+				err = io.ErrUnexpectedEOF
+			}
+		}
+		res.Body.Close()
+	}
+	if err != nil {
+		g.addProgress(-writer.written)
+		if retry < gdriveconstants.MaxRetries && err != constants.CancelledByUserError {
+			logger.Debug("Retrying download segment", zap.String("fileID", file.Id), zap.Int("segment", s.index), zap.Int("retry", retry), zap.Error(err))
+			return g.downloadSegment(file, s, retry+1)
+		}
+		return err
+	}
+	return nil
+}
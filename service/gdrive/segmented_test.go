@@ -0,0 +1,58 @@
+package gdrive
+
+import (
+	"testing"
+)
+
+func TestSplitSegments(t *testing.T) {
+	tests := []struct {
+		name        string
+		size        int64
+		segmentSize int64
+		want        [][2]int64
+	}{
+		{
+			name:        "empty file",
+			size:        0,
+			segmentSize: 10,
+			want:        nil,
+		},
+		{
+			name:        "smaller than one segment",
+			size:        7,
+			segmentSize: 10,
+			want:        [][2]int64{{0, 6}},
+		},
+		{
+			name:        "exactly one segment",
+			size:        10,
+			segmentSize: 10,
+			want:        [][2]int64{{0, 9}},
+		},
+		{
+			name:        "exact multiple",
+			size:        30,
+			segmentSize: 10,
+			want:        [][2]int64{{0, 9}, {10, 19}, {20, 29}},
+		},
+		{
+			name:        "short last segment",
+			size:        25,
+			segmentSize: 10,
+			want:        [][2]int64{{0, 9}, {10, 19}, {20, 24}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := splitSegments(test.size, test.segmentSize)
+			if len(got) != len(test.want) {
+				t.Fatalf("splitSegments(%d, %d) gave %d segments, want %d", test.size, test.segmentSize, len(got), len(test.want))
+			}
+			for i, seg := range got {
+				if seg.index != i || seg.start != test.want[i][0] || seg.end != test.want[i][1] {
+					t.Errorf("segment %d = {%d %d-%d}, want {%d %d-%d}", i, seg.index, seg.start, seg.end, i, test.want[i][0], test.want[i][1])
+				}
+			}
+		})
+	}
+}
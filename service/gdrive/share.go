@@ -0,0 +1,148 @@
+package gdrive
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+
+	"github.com/jaskaranSM/transfer-service/logging"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+	"github.com/jaskaranSM/transfer-service/types"
+)
+
+const permissionFields = "id,type,role,emailAddress,domain,displayName,allowFileDiscovery,expirationTime"
+
+var permissionRoles = map[string]bool{
+	"owner":         true,
+	"organizer":     true,
+	"fileOrganizer": true,
+	"writer":        true,
+	"commenter":     true,
+	"reader":        true,
+}
+
+func checkRole(role string) error {
+	if !permissionRoles[role] {
+		return fmt.Errorf("unknown permission role: %s", role)
+	}
+	return nil
+}
+
+// CheckPermission validates opts before anything is sent to Drive, submit
+// requests check their share block up front.
+func CheckPermission(opts *types.PermissionOptions) error {
+	err := checkRole(opts.Role)
+	if err != nil {
+		return err
+	}
+	switch opts.Type {
+	case gdriveconstants.PermissionUser, gdriveconstants.PermissionGroup:
+		if opts.EmailAddress == "" {
+			return fmt.Errorf("%s permissions need an email_address", opts.Type)
+		}
+	case gdriveconstants.PermissionDomain:
+		if opts.Domain == "" {
+			return fmt.Errorf("domain permissions need a domain")
+		}
+	case gdriveconstants.PermissionAnyone:
+	default:
+		return fmt.Errorf("unknown permission type: %s", opts.Type)
+	}
+	return nil
+}
+
+func (gd *GoogleDriveClient) ListPermissions(fileId string) ([]*drive.Permission, error) {
+	var permissions []*drive.Permission
+	err := gd.DriveSrv.Permissions.List(fileId).SupportsAllDrives(true).
+		Fields(googleapi.Field("nextPageToken,permissions("+permissionFields+")")).
+		Pages(context.Background(), func(res *drive.PermissionList) error {
+			permissions = append(permissions, res.Permissions...)
+			return nil
+		})
+	if err != nil {
+		logging.GetLogger().Error("Could not list permissions", zap.Error(err), zap.String("fileId", fileId))
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (gd *GoogleDriveClient) CreatePermission(fileId string, opts *types.PermissionOptions) (*drive.Permission, error) {
+	err := CheckPermission(opts)
+	if err != nil {
+		return nil, err
+	}
+	permission := &drive.Permission{
+		Type:         opts.Type,
+		Role:         opts.Role,
+		EmailAddress: opts.EmailAddress,
+		Domain:       opts.Domain,
+	}
+	if opts.Type == gdriveconstants.PermissionDomain || opts.Type == gdriveconstants.PermissionAnyone {
+		permission.AllowFileDiscovery = opts.AllowFileDiscovery
+	}
+	call := gd.DriveSrv.Permissions.Create(fileId, permission).SupportsAllDrives(true).
+		Fields(googleapi.Field(permissionFields))
+	if opts.Role == "owner" {
+		call = call.TransferOwnership(true)
+	}
+	if opts.Type == gdriveconstants.PermissionUser || opts.Type == gdriveconstants.PermissionGroup {
+		call = call.SendNotificationEmail(opts.SendNotification)
+		if opts.SendNotification && opts.Message != "" {
+			call = call.EmailMessage(opts.Message)
+		}
+	}
+	created, err := call.Do()
+	if err != nil {
+		logging.GetLogger().Error("Could not create permission", zap.Error(err), zap.String("fileId", fileId),
+			zap.String("type", opts.Type), zap.String("role", opts.Role))
+		return nil, err
+	}
+	return created, nil
+}
+
+func (gd *GoogleDriveClient) UpdatePermission(fileId string, permissionId string, role string) (*drive.Permission, error) {
+	err := checkRole(role)
+	if err != nil {
+		return nil, err
+	}
+	call := gd.DriveSrv.Permissions.Update(fileId, permissionId, &drive.Permission{Role: role}).SupportsAllDrives(true).
+		Fields(googleapi.Field(permissionFields))
+	if role == "owner" {
+		call = call.TransferOwnership(true)
+	}
+	updated, err := call.Do()
+	if err != nil {
+		logging.GetLogger().Error("Could not update permission", zap.Error(err), zap.String("fileId", fileId),
+			zap.String("permissionId", permissionId))
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (gd *GoogleDriveClient) DeletePermission(fileId string, permissionId string) error {
+	err := gd.DriveSrv.Permissions.Delete(fileId, permissionId).SupportsAllDrives(true).Do()
+	if err != nil {
+		logging.GetLogger().Error("Could not delete permission", zap.Error(err), zap.String("fileId", fileId),
+			zap.String("permissionId", permissionId))
+	}
+	return err
+}
+
+// Share grants every permission in share on fileId and returns the link
+// the file can be opened with.
+func (gd *GoogleDriveClient) Share(fileId string, share []types.PermissionOptions) (string, error) {
+	for i := range share {
+		_, err := gd.CreatePermission(fileId, &share[i])
+		if err != nil {
+			return "", err
+		}
+	}
+	file, err := gd.DriveSrv.Files.Get(fileId).Fields("webViewLink").SupportsAllDrives(true).Do()
+	if err != nil {
+		return "", err
+	}
+	return file.WebViewLink, nil
+}
@@ -0,0 +1,113 @@
+package gdrive
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/drive/v3"
+
+	"github.com/jaskaranSM/transfer-service/logging"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+)
+
+var errShortcutCycle = errors.New("shortcut points to one of its own parents")
+
+// ShortcutCounters reports what happened to the shortcuts met by a job.
+type ShortcutCounters struct {
+	Followed int `json:"followed"`
+	Copied   int `json:"copied"`
+	Skipped  int `json:"skipped"`
+}
+
+// SetShortcutPolicy decides what walkers do with Drive shortcuts: follow
+// transfers the target, copy clones the shortcut itself and skip leaves it
+// out. The empty policy copies on clone and skips everywhere else.
+func (gd *GoogleDriveClient) SetShortcutPolicy(policy string) error {
+	switch policy {
+	case "", gdriveconstants.ShortcutFollow, gdriveconstants.ShortcutCopy, gdriveconstants.ShortcutSkip:
+	default:
+		return fmt.Errorf("unknown shortcut policy: %s", policy)
+	}
+	gd.shortcutPolicy = policy
+	return nil
+}
+
+func (gd *GoogleDriveClient) Shortcuts() ShortcutCounters {
+	gd.mut.Lock()
+	defer gd.mut.Unlock()
+	return gd.shortcuts
+}
+
+func IsShortcut(file *drive.File) bool {
+	return file.MimeType == gdriveconstants.ShortcutMimeType
+}
+
+// appendChain returns chain extended by id without sharing chain's storage.
+func appendChain(chain []string, id string) []string {
+	return append(append([]string{}, chain...), id)
+}
+
+// resolveShortcut returns the target of shortcut under the shortcut's name.
+// chain holds the source folder ids from the walk root down to the folder
+// the shortcut lives in, a folder target already on it is a cycle.
+func (gd *GoogleDriveClient) resolveShortcut(shortcut *drive.File, chain []string) (*drive.File, error) {
+	if shortcut.ShortcutDetails == nil {
+		return nil, errors.New("shortcut without target")
+	}
+	for _, id := range chain {
+		if id == shortcut.ShortcutDetails.TargetId {
+			return nil, errShortcutCycle
+		}
+	}
+	target, err := gd.GetFileMetadata(shortcut.ShortcutDetails.TargetId)
+	if err != nil {
+		return nil, err
+	}
+	target.Name = shortcut.Name
+	return target, nil
+}
+
+func (gd *GoogleDriveClient) countShortcut(action string) {
+	gd.mut.Lock()
+	defer gd.mut.Unlock()
+	switch action {
+	case gdriveconstants.ShortcutFollow:
+		gd.shortcuts.Followed += 1
+	case gdriveconstants.ShortcutCopy:
+		gd.shortcuts.Copied += 1
+	default:
+		gd.shortcuts.Skipped += 1
+	}
+}
+
+// handleShortcut applies the shortcut policy while walking. It returns the
+// entry the walker should continue with, the target, the shortcut itself
+// when it is copied, or nil when the shortcut is skipped.
+func (gd *GoogleDriveClient) handleShortcut(shortcut *drive.File, chain []string, canCopy bool) *drive.File {
+	logger := logging.GetLogger()
+	policy := gd.shortcutPolicy
+	if policy == "" {
+		policy = gdriveconstants.ShortcutSkip
+		if canCopy {
+			policy = gdriveconstants.ShortcutCopy
+		}
+	}
+	switch {
+	case policy == gdriveconstants.ShortcutFollow:
+		target, err := gd.resolveShortcut(shortcut, chain)
+		if err != nil {
+			logger.Error("Skipping shortcut that can not be followed", zap.Error(err), zap.String("name", shortcut.Name), zap.String("fileID", shortcut.Id))
+			gd.countShortcut(gdriveconstants.ShortcutSkip)
+			return nil
+		}
+		gd.countShortcut(gdriveconstants.ShortcutFollow)
+		return target
+	case policy == gdriveconstants.ShortcutCopy && canCopy:
+		gd.countShortcut(gdriveconstants.ShortcutCopy)
+		return shortcut
+	}
+	logger.Debug("Skipping shortcut", zap.String("name", shortcut.Name), zap.String("fileID", shortcut.Id))
+	gd.countShortcut(gdriveconstants.ShortcutSkip)
+	return nil
+}
@@ -0,0 +1,209 @@
+package gdrive
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/api/drive/v3"
+
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+)
+
+// ErrFolderTooBig stops a synchronous stats walk which went past its folder
+// limit, the caller should start a stats job instead.
+var ErrFolderTooBig = errors.New("folder is too big to be counted right away")
+
+type StatCount struct {
+	Files int   `json:"files"`
+	Bytes int64 `json:"bytes"`
+}
+
+// FolderStats describes everything below a folder. Shortcuts are counted
+// but not followed, Google native files have no size.
+type FolderStats struct {
+	TotalBytes int64                 `json:"total_bytes"`
+	Files      int                   `json:"files"`
+	Folders    int                   `json:"folders"`
+	Native     int                   `json:"native"`
+	Shortcuts  int                   `json:"shortcuts"`
+	MimeTypes  map[string]*StatCount `json:"mime_types"`
+	Extensions map[string]*StatCount `json:"extensions"`
+	Largest    []*drive.File         `json:"largest"`
+}
+
+// statsCollector adds up FolderStats from several walk workers.
+type statsCollector struct {
+	mut   sync.Mutex
+	top   int
+	stats FolderStats
+}
+
+func newStatsCollector(top int) *statsCollector {
+	if top <= 0 {
+		top = gdriveconstants.StatsDefaultTop
+	}
+	if top > gdriveconstants.StatsMaxTop {
+		top = gdriveconstants.StatsMaxTop
+	}
+	return &statsCollector{
+		top: top,
+		stats: FolderStats{
+			MimeTypes:  make(map[string]*StatCount),
+			Extensions: make(map[string]*StatCount),
+		},
+	}
+}
+
+func countStat(counts map[string]*StatCount, key string, size int64) {
+	count, ok := counts[key]
+	if !ok {
+		count = &StatCount{}
+		counts[key] = count
+	}
+	count.Files += 1
+	count.Bytes += size
+}
+
+func (c *statsCollector) add(file *drive.File) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	stats := &c.stats
+	if file.MimeType == "application/vnd.google-apps.folder" {
+		stats.Folders += 1
+		return
+	}
+	if IsShortcut(file) {
+		stats.Shortcuts += 1
+		return
+	}
+	if strings.HasPrefix(file.MimeType, gdriveconstants.GoogleAppsMimePrefix) {
+		stats.Native += 1
+	}
+	stats.Files += 1
+	stats.TotalBytes += file.Size
+	countStat(stats.MimeTypes, file.MimeType, file.Size)
+	countStat(stats.Extensions, strings.ToLower(filepath.Ext(file.Name)), file.Size)
+	if len(stats.Largest) == c.top && stats.Largest[c.top-1].Size >= file.Size {
+		return
+	}
+	i := sort.Search(len(stats.Largest), func(i int) bool {
+		return stats.Largest[i].Size < file.Size
+	})
+	stats.Largest = append(stats.Largest, nil)
+	copy(stats.Largest[i+1:], stats.Largest[i:])
+	stats.Largest[i] = file
+	if len(stats.Largest) > c.top {
+		stats.Largest = stats.Largest[:c.top]
+	}
+}
+
+func (c *statsCollector) snapshot() *FolderStats {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	stats := c.stats
+	stats.MimeTypes = make(map[string]*StatCount)
+	for key, count := range c.stats.MimeTypes {
+		stats.MimeTypes[key] = &StatCount{Files: count.Files, Bytes: count.Bytes}
+	}
+	stats.Extensions = make(map[string]*StatCount)
+	for key, count := range c.stats.Extensions {
+		stats.Extensions[key] = &StatCount{Files: count.Files, Bytes: count.Bytes}
+	}
+	stats.Largest = append([]*drive.File(nil), c.stats.Largest...)
+	return &stats
+}
+
+// walkStats feeds every entry below folderId to collector. With maxFolders
+// above 0 the walk gives up with ErrFolderTooBig after that many folders.
+func (gd *GoogleDriveClient) walkStats(folderId string, collector *statsCollector, maxFolders int, counted func(*drive.File)) error {
+	var folders int64
+	root := &walkItem{
+		src: folderId,
+	}
+	return gd.walkTree(root, func(dirItem *walkItem) ([]*walkItem, error) {
+		if maxFolders > 0 && atomic.AddInt64(&folders, 1) > int64(maxFolders) {
+			return nil, ErrFolderTooBig
+		}
+		files, err := gd.ListFilesByParentId(dirItem.src, "", -1)
+		if err != nil {
+			return nil, err
+		}
+		var children []*walkItem
+		for _, file := range files {
+			collector.add(file)
+			if counted != nil {
+				counted(file)
+			}
+			if gd.IsDir(file) {
+				children = append(children, dirItem.child(file.Id, "", file.Name))
+			}
+		}
+		return children, nil
+	})
+}
+
+// GetFolderStats counts the folder right away, it fails with
+// ErrFolderTooBig when the tree holds more than maxFolders folders.
+func (gd *GoogleDriveClient) GetFolderStats(folderId string, top int, maxFolders int) (*FolderStats, error) {
+	collector := newStatsCollector(top)
+	err := gd.walkStats(folderId, collector, maxFolders, nil)
+	if err != nil {
+		return nil, err
+	}
+	return collector.snapshot(), nil
+}
+
+// FolderStats returns what a stats job counted so far, nil for any other
+// transfer.
+func (gd *GoogleDriveClient) FolderStats() *FolderStats {
+	gd.mut.Lock()
+	collector := gd.stats
+	gd.mut.Unlock()
+	if collector == nil {
+		return nil
+	}
+	return collector.snapshot()
+}
+
+// Stats counts the folder as a tracked job, counted files and bytes are
+// reported as both completed and total while the tree is walked.
+func (gd *GoogleDriveClient) Stats(folderId string, top int) error {
+	gd.listener.OnTransferStart(gd)
+	meta, err := gd.GetFileMetadata(folderId)
+	if err != nil {
+		gd.listener.OnTransferError(gd, err)
+		return err
+	}
+	if !gd.IsDir(meta) {
+		err = fmt.Errorf("%s is not a folder", meta.Name)
+		gd.listener.OnTransferError(gd, err)
+		return err
+	}
+	gd.Name = meta.Name
+	collector := newStatsCollector(top)
+	gd.mut.Lock()
+	gd.stats = collector
+	gd.mut.Unlock()
+	err = gd.walkStats(folderId, collector, 0, func(file *drive.File) {
+		if gd.IsDir(file) || IsShortcut(file) {
+			return
+		}
+		gd.mut.Lock()
+		defer gd.mut.Unlock()
+		gd.completedFiles += 1
+		gd.completed += file.Size
+		// the total is only known once the walk is done, grow it along
+		gd.total += file.Size
+	})
+	if err != nil {
+		gd.listener.OnTransferError(gd, err)
+		return err
+	}
+	gd.listener.OnTransferComplete(gd, folderId)
+	return nil
+}
@@ -0,0 +1,303 @@
+package gdrive
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+	"google.golang.org/api/drive/v3"
+
+	"github.com/jaskaranSM/transfer-service/logging"
+	gdriveconstants "github.com/jaskaranSM/transfer-service/service/gdrive/constants"
+	"github.com/jaskaranSM/transfer-service/utils"
+)
+
+// SetMirror turns on deletion of destination entries which are missing from
+// the source of a sync.
+func (gd *GoogleDriveClient) SetMirror(deleteExtraneous bool) {
+	gd.deleteExtraneous = deleteExtraneous
+}
+
+func (gd *GoogleDriveClient) DeletedFiles() int {
+	gd.mut.Lock()
+	defer gd.mut.Unlock()
+	return gd.deletedFiles
+}
+
+func (gd *GoogleDriveClient) countDeleted() {
+	gd.mut.Lock()
+	defer gd.mut.Unlock()
+	gd.deletedFiles += 1
+}
+
+func (gd *GoogleDriveClient) TrashFile(fileId string) error {
+	logger := logging.GetLogger()
+	_, err := gd.DriveSrv.Files.Update(fileId, &drive.File{Trashed: true}).SupportsAllDrives(true).Do()
+	invalidateMetadata(fileId)
+	if err != nil {
+		logger.Error("Could not trash file", zap.Error(err), zap.String("fileID", fileId))
+		return err
+	}
+	return nil
+}
+
+// trashExtraneous trashes the entries of a mirrored destination folder that
+// were not matched by a source entry, kept holds the ids that were matched.
+func (gd *GoogleDriveClient) trashExtraneous(parentId string, entries *destEntries, kept map[string]bool) error {
+	if !gd.deleteExtraneous {
+		return nil
+	}
+	logger := logging.GetLogger()
+	for _, file := range entries.all {
+		if kept[file.Id] {
+			continue
+		}
+		if gd.plan != nil {
+			gd.plan.add(&PlanEntry{
+				Action:   gdriveconstants.PlanDelete,
+				Path:     gd.plan.destPath(parentId, file.Name),
+				Size:     file.Size,
+				TargetId: file.Id,
+			}, 1)
+			continue
+		}
+		logger.Debug("Trashing extraneous entry", zap.String("name", file.Name), zap.String("fileID", file.Id))
+		err := gd.TrashFile(file.Id)
+		if err != nil {
+			return err
+		}
+		gd.countDeleted()
+	}
+	return nil
+}
+
+// SyncUpload mirrors the local directory dir into the Drive folder parentId,
+// only new or changed files are sent and changed files get a new revision.
+func (gd *GoogleDriveClient) SyncUpload(dir string, parentId string) error {
+	logger := logging.GetLogger()
+	gd.Name = filepath.Base(dir)
+	gd.listener.OnTransferStart(gd)
+	stat, err := os.Stat(dir)
+	if err != nil {
+		logger.Error("Could not get stats of file path", zap.Error(err),
+			zap.String("file path", dir),
+		)
+		gd.listener.OnTransferError(gd, err)
+		return err
+	}
+	if !stat.IsDir() {
+		err = errors.New("sync source is not a directory")
+		gd.listener.OnTransferError(gd, err)
+		return err
+	}
+	gd.incremental = true
+	gd.compareModTime = true
+	err = gd.UploadDir(dir, parentId)
+	if err != nil {
+		gd.failWalk(err)
+		return nil
+	}
+	gd.wg.Wait()
+	for _, tr := range gd.transfers() {
+		if tr.isCompleted == false {
+			return tr.err
+		}
+	}
+	gd.listener.OnTransferComplete(gd, parentId)
+	return nil
+}
+
+// isDownloaded reports whether the local file at path already holds the
+// content of file, compared by size and md5 or modifiedTime.
+func (gd *GoogleDriveClient) isDownloaded(path string, file *drive.File) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	if IsGoogleNative(file) {
+		// exports have no size or md5 on Drive
+		return sameModTime(info.ModTime(), file.ModifiedTime)
+	}
+	if info.Size() != file.Size {
+		return false
+	}
+	if !gd.compareMd5 {
+		return sameModTime(info.ModTime(), file.ModifiedTime)
+	}
+	sum, err := utils.GetFileMd5(path)
+	if err != nil {
+		logging.GetLogger().Error("Could not hash local file", zap.Error(err), zap.String("path", path))
+		return false
+	}
+	return sum == file.Md5Checksum
+}
+
+// removeExtraneousLocal deletes the entries of a mirrored local directory
+// that are not in kept. Partial files of kept entries are left alone so
+// interrupted downloads can still resume.
+func (gd *GoogleDriveClient) removeExtraneousLocal(localDir string, kept map[string]bool) error {
+	if !gd.deleteExtraneous {
+		return nil
+	}
+	logger := logging.GetLogger()
+	entries, err := os.ReadDir(localDir)
+	if err != nil {
+		if gd.plan != nil && os.IsNotExist(err) {
+			// a dry run did not create the directory
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), gdriveconstants.PartialMetaSuffix)
+		name = strings.TrimSuffix(name, gdriveconstants.PartialFileSuffix)
+		if kept[entry.Name()] || (name != entry.Name() && kept[name]) {
+			continue
+		}
+		localPath := filepath.Join(localDir, entry.Name())
+		if gd.plan != nil {
+			gd.plan.add(&PlanEntry{
+				Action: gdriveconstants.PlanDelete,
+				Path:   localPath,
+			}, 0)
+			continue
+		}
+		logger.Debug("Removing extraneous local entry", zap.String("path", localPath))
+		err = os.RemoveAll(localPath)
+		if err != nil {
+			logger.Error("Could not remove local entry", zap.Error(err), zap.String("path", localPath))
+			return err
+		}
+		gd.countDeleted()
+	}
+	return nil
+}
+
+// SyncDownload mirrors the Drive folder folderId into the local directory
+// localDir, only files whose size and md5 or modifiedTime differ are fetched.
+func (gd *GoogleDriveClient) SyncDownload(folderId string, localDir string) error {
+	logger := logging.GetLogger()
+	gd.listener.OnTransferStart(gd)
+	meta, err := gd.GetFileMetadata(folderId)
+	if err != nil {
+		gd.listener.OnTransferError(gd, err)
+		return nil
+	}
+	if !gd.IsDir(meta) {
+		err = errors.New("sync source is not a folder")
+		gd.listener.OnTransferError(gd, err)
+		return err
+	}
+	if gd.total == 0 {
+		gd.Name = "getting metadata"
+		err = gd.GetFolderSize(meta.Id, &gd.total, true)
+		if err != nil {
+			gd.listener.OnTransferError(gd, err)
+			return err
+		}
+	}
+	gd.Name = meta.Name
+	err = gd.mkdirLocal(localDir)
+	if err != nil {
+		logger.Error("Could create directories", zap.Error(err),
+			zap.String("file path", localDir),
+		)
+		gd.listener.OnTransferError(gd, err)
+		return nil
+	}
+	gd.incremental = true
+	err = gd.DownloadDir(meta, localDir)
+	if err != nil {
+		gd.failWalk(err)
+		return nil
+	}
+	gd.wg.Wait()
+	for _, tr := range gd.transfers() {
+		if tr.isCompleted == false {
+			return tr.err
+		}
+	}
+	gd.listener.OnTransferComplete(gd, meta.Id)
+	return nil
+}
+
+// isCloned reports whether existing is an up to date copy of file. Copies
+// keep the source md5 and modifiedTime, Google native files have no md5.
+func isCloned(file *drive.File, existing *drive.File) bool {
+	if existing == nil || existing.Size != file.Size {
+		return false
+	}
+	if file.Md5Checksum != "" && existing.Md5Checksum != "" {
+		return file.Md5Checksum == existing.Md5Checksum
+	}
+	return file.ModifiedTime == existing.ModifiedTime
+}
+
+// handleIncrementalClone copies file into desId unless existing is already
+// a copy of it, an outdated existing copy is trashed once the new one landed.
+func (gd *GoogleDriveClient) handleIncrementalClone(file *drive.File, desId string, existing *drive.File) error {
+	logger := logging.GetLogger()
+	if isCloned(file, existing) {
+		logger.Debug("Skipping already cloned file", zap.String("name", file.Name), zap.String("id", existing.Id))
+		gd.skipFile(gd.plan.destPath(desId, file.Name), file.Size)
+		return nil
+	}
+	if gd.plan != nil && existing != nil {
+		gd.planClone(gdriveconstants.PlanOverwrite, file, "", desId, existing.Id)
+		return nil
+	}
+	return gd.HandleCloneFile(file, desId, func(f *drive.File) {
+		if existing == nil {
+			return
+		}
+		err := gd.TrashFile(existing.Id)
+		if err != nil {
+			logger.Error("Could not trash outdated copy", zap.Error(err), zap.String("fileID", existing.Id))
+			return
+		}
+		gd.countDeleted()
+	})
+}
+
+// SyncClone mirrors the Drive folder srcId into the Drive folder desId with
+// server side copies of the missing or changed files only.
+func (gd *GoogleDriveClient) SyncClone(srcId string, desId string) error {
+	logger := logging.GetLogger()
+	logger.Info("starting sync clone", zap.String("srcId", srcId), zap.String("desId", desId))
+	gd.listener.OnTransferStart(gd)
+	meta, err := gd.GetFileMetadata(srcId)
+	if err != nil {
+		gd.listener.OnTransferError(gd, err)
+		return err
+	}
+	if !gd.IsDir(meta) {
+		err = errors.New("sync source is not a folder")
+		gd.listener.OnTransferError(gd, err)
+		return err
+	}
+	if gd.total == 0 {
+		gd.Name = "getting metadata"
+		err = gd.GetFolderSize(meta.Id, &gd.total, false)
+		if err != nil {
+			gd.listener.OnTransferError(gd, err)
+			return err
+		}
+	}
+	gd.Name = meta.Name
+	gd.incremental = true
+	err = gd.CloneDir(meta, desId)
+	if err != nil {
+		gd.failWalk(err)
+		return err
+	}
+	gd.wg.Wait()
+	for _, tr := range gd.transfers() {
+		if tr.isCompleted == false {
+			return tr.err
+		}
+	}
+	gd.listener.OnTransferComplete(gd, desId)
+	return nil
+}
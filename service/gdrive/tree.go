@@ -0,0 +1,80 @@
+package gdrive
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// TreeEntry is one file of a recursive listing, Children is only filled in
+// for tree output.
+type TreeEntry struct {
+	Path     string       `json:"path"`
+	Depth    int          `json:"depth"`
+	File     *drive.File  `json:"file"`
+	Children []*TreeEntry `json:"children,omitempty"`
+	parentId string
+}
+
+// ListTree lists everything below parentId down to depth levels, 0 walks
+// the whole tree. visit is called once per entry and never concurrently,
+// an error from visit stops the walk. Shortcuts are listed, not followed.
+func (gd *GoogleDriveClient) ListTree(parentId string, depth int, fields []string, visit func(*TreeEntry) error) error {
+	fields, err := FileFields(fields)
+	if err != nil {
+		return err
+	}
+	listFields := fmt.Sprintf("nextPageToken,files(%s)", strings.Join(fields, ","))
+	query := "'%s' in parents and trashed = false"
+	var visitMut sync.Mutex
+	root := &walkItem{
+		src: parentId,
+	}
+	return gd.walkTree(root, func(dirItem *walkItem) ([]*walkItem, error) {
+		files, err := gd.listFilesWithFields(fmt.Sprintf(query, escapeQuery(dirItem.src)), -1, listFields)
+		if err != nil {
+			return nil, err
+		}
+		level := len(dirItem.chain) + 1
+		var children []*walkItem
+		visitMut.Lock()
+		defer visitMut.Unlock()
+		for _, file := range files {
+			entry := &TreeEntry{
+				Path:     joinRel(dirItem.rel, file.Name),
+				Depth:    level,
+				File:     file,
+				parentId: dirItem.src,
+			}
+			err = visit(entry)
+			if err != nil {
+				return nil, err
+			}
+			if gd.IsDir(file) && (depth <= 0 || level < depth) {
+				children = append(children, dirItem.child(file.Id, "", file.Name))
+			}
+		}
+		return children, nil
+	})
+}
+
+// BuildTree nests the entries of a listing of rootId below their folders.
+func BuildTree(rootId string, entries []*TreeEntry) []*TreeEntry {
+	folders := make(map[string]*TreeEntry)
+	for _, entry := range entries {
+		if entry.File.MimeType == "application/vnd.google-apps.folder" {
+			folders[entry.File.Id] = entry
+		}
+	}
+	var roots []*TreeEntry
+	for _, entry := range entries {
+		if entry.parentId == rootId {
+			roots = append(roots, entry)
+		} else if parent, ok := folders[entry.parentId]; ok {
+			parent.Children = append(parent.Children, entry)
+		}
+	}
+	return roots
+}
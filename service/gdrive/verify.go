@@ -0,0 +1,128 @@
+package gdrive
+
+import (
+	"crypto/md5"
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"go.uber.org/zap"
+
+	"github.com/jaskaranSM/transfer-service/logging"
+)
+
+type ChecksumMismatchError struct {
+	Name     string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s: expected md5 %s, got %s", e.Name, e.Expected, e.Actual)
+}
+
+// resetHash moves the running checksum to offset. A checksum behind offset
+// catches up from g.file, one past it restarts from the last checkpoint at
+// or before offset, so only the bytes after that are read back from disk.
+func (g *GoogleDriveFileTransfer) resetHash(offset int64) error {
+	if !g.verify {
+		return nil
+	}
+	if g.hash == nil || g.hashed > offset {
+		g.hash = md5.New()
+		g.hashed = 0
+		if g.hashState != nil && g.hashStateAt <= offset {
+			err := g.hash.(encoding.BinaryUnmarshaler).UnmarshalBinary(g.hashState)
+			if err != nil {
+				return err
+			}
+			g.hashed = g.hashStateAt
+		}
+	}
+	n, err := io.Copy(g.hash, io.NewSectionReader(g.file, g.hashed, offset-g.hashed))
+	g.hashed += n
+	return err
+}
+
+// checkpointHash saves the checksum state once it covers exactly offset
+// bytes, callers checkpoint at offsets Drive has committed.
+func (g *GoogleDriveFileTransfer) checkpointHash(offset int64) error {
+	if g.hash == nil || g.hashed != offset {
+		return nil
+	}
+	state, err := g.hash.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return err
+	}
+	g.hashState = state
+	g.hashStateAt = offset
+	return nil
+}
+
+// clearHash drops the checksum and its checkpoint, the next check hashes
+// g.file from the start.
+func (g *GoogleDriveFileTransfer) clearHash() {
+	g.hash = nil
+	g.hashed = 0
+	g.hashState = nil
+	g.hashStateAt = 0
+}
+
+func (g *GoogleDriveFileTransfer) updateHash(p []byte) {
+	if g.hash == nil {
+		return
+	}
+	g.hash.Write(p)
+	g.hashed += int64(len(p))
+}
+
+// checkChecksum compares the md5 of the size bytes that went through g.file
+// with the one Drive reports.
+func (g *GoogleDriveFileTransfer) checkChecksum(name string, expected string, size int64) error {
+	if !g.verify {
+		return nil
+	}
+	if expected == "" {
+		logging.GetLogger().Debug("No md5Checksum to verify against", zap.String("name", name))
+		return nil
+	}
+	if g.hash == nil || g.hashed != size {
+		err := g.resetHash(size)
+		if err != nil {
+			return err
+		}
+	}
+	actual := hex.EncodeToString(g.hash.Sum(nil))
+	if actual != expected {
+		return &ChecksumMismatchError{
+			Name:     name,
+			Expected: expected,
+			Actual:   actual,
+		}
+	}
+	return nil
+}
+
+func (g *GoogleDriveFileTransfer) compareChecksums(name string, expected string, actual string) error {
+	if !g.verify || expected == "" {
+		return nil
+	}
+	if actual != expected {
+		return &ChecksumMismatchError{
+			Name:     name,
+			Expected: expected,
+			Actual:   actual,
+		}
+	}
+	return nil
+}
+
+// discardFile removes a bad copy that failed verification.
+func (g *GoogleDriveFileTransfer) discardFile(fileId string) {
+	err := g.service.Files.Delete(fileId).SupportsAllDrives(true).Do()
+	invalidateMetadata(fileId)
+	if err != nil {
+		logging.GetLogger().Error("Could not delete file that failed verification", zap.Error(err), zap.String("fileID", fileId))
+	}
+}
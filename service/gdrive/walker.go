@@ -0,0 +1,130 @@
+package gdrive
+
+import (
+	"errors"
+	"sync"
+
+	"google.golang.org/api/drive/v3"
+
+	"github.com/jaskaranSM/transfer-service/config"
+)
+
+// walkItem is a folder waiting to be walked, src and des are ids or local
+// paths depending on the direction of the transfer.
+type walkItem struct {
+	src string
+	des string
+	// rel is the slash separated path below the walk root filters match
+	rel string
+	// chain holds the source folder ids from the root, see resolveShortcut
+	chain []string
+	// merged holds further source folders which land in des as well
+	merged []string
+}
+
+func (w *walkItem) child(src string, des string, name string) *walkItem {
+	return &walkItem{
+		src:   src,
+		des:   des,
+		rel:   joinRel(w.rel, name),
+		chain: appendChain(w.chain, src),
+	}
+}
+
+func (w *walkItem) sources() []string {
+	return append([]string{w.src}, w.merged...)
+}
+
+// addChild queues the source folder src for des. Sources which land in the
+// same destination folder, such as folders sharing a name that are merged,
+// are walked as one item so a mirror sees all of their entries before it
+// trashes anything.
+func addChild(children []*walkItem, parent *walkItem, src string, des string, name string) []*walkItem {
+	for _, child := range children {
+		if child.des == des {
+			child.merged = append(child.merged, src)
+			child.chain = appendChain(child.chain, src)
+			return children
+		}
+	}
+	return append(children, parent.child(src, des, name))
+}
+
+// listSources lists the entries of every Drive source folder of dirItem.
+func (gd *GoogleDriveClient) listSources(dirItem *walkItem) ([]*drive.File, error) {
+	var files []*drive.File
+	for _, src := range dirItem.sources() {
+		listed, err := gd.ListFilesByParentId(src, "", -1)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, listed...)
+	}
+	return files, nil
+}
+
+// treeWalker hands folders to a fixed number of workers. A folder is only
+// queued once its destination exists, so workers can list, create folders
+// and start transfers for different folders at the same time.
+type treeWalker struct {
+	mut     sync.Mutex
+	cond    *sync.Cond
+	pending []*walkItem
+	active  int
+	err     error
+}
+
+// walkTree calls visit for root and every folder visit returns, with up to
+// WALK_CONCURRENCY folders in flight. The first error stops the walk.
+func (gd *GoogleDriveClient) walkTree(root *walkItem, visit func(*walkItem) ([]*walkItem, error)) error {
+	workers := config.Get().WalkConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	w := &treeWalker{
+		pending: []*walkItem{root},
+	}
+	w.cond = sync.NewCond(&w.mut)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gd.walkWorker(w, visit)
+		}()
+	}
+	wg.Wait()
+	return w.err
+}
+
+func (gd *GoogleDriveClient) walkWorker(w *treeWalker, visit func(*walkItem) ([]*walkItem, error)) {
+	for {
+		w.mut.Lock()
+		for len(w.pending) == 0 && w.active > 0 && w.err == nil {
+			w.cond.Wait()
+		}
+		if w.err == nil && gd.isCancelled {
+			w.err = errors.New("cancelled by user")
+		}
+		if w.err != nil || len(w.pending) == 0 {
+			w.mut.Unlock()
+			w.cond.Broadcast()
+			return
+		}
+		item := w.pending[0]
+		w.pending = w.pending[1:]
+		w.active += 1
+		w.mut.Unlock()
+
+		children, err := visit(item)
+
+		w.mut.Lock()
+		w.active -= 1
+		if err != nil && w.err == nil {
+			w.err = err
+		}
+		w.pending = append(w.pending, children...)
+		w.mut.Unlock()
+		w.cond.Broadcast()
+	}
+}
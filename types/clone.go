@@ -1,8 +1,14 @@
 package types
 
 type CloneRequest struct {
-	FileId      string `json:"file_id"`
-	DesId       string `json:"des_id"`
-	Concurrency int    `json:"concurrency"`
-	Size        int64  `json:"size"`
+	FileId      string              `json:"file_id"`
+	DesId       string              `json:"des_id"`
+	Concurrency int                 `json:"concurrency"`
+	Size        int64               `json:"size"`
+	Verify      bool                `json:"verify"`
+	Conflict    ConflictOptions     `json:"conflict"`
+	Filter      FilterOptions       `json:"filter"`
+	DryRun      bool                `json:"dry_run"`
+	Shortcuts   string              `json:"shortcuts"`
+	Share       []PermissionOptions `json:"share"`
 }
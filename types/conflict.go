@@ -0,0 +1,6 @@
+package types
+
+type ConflictOptions struct {
+	Folders string `json:"folders"`
+	Files   string `json:"files"`
+}
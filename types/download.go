@@ -1,8 +1,14 @@
 package types
 
 type DownloadRequest struct {
-	FileId      string `json:"file_id"`
-	LocalDir    string `json:"local_dir"`
-	Size        int64  `json:"size"`
-	Concurrency int    `json:"concurrency"`
+	FileId      string          `json:"file_id"`
+	LocalDir    string          `json:"local_dir"`
+	Size        int64           `json:"size"`
+	Concurrency int             `json:"concurrency"`
+	Verify      bool            `json:"verify"`
+	Conflict    ConflictOptions `json:"conflict"`
+	Filter      FilterOptions   `json:"filter"`
+	DryRun      bool            `json:"dry_run"`
+	Shortcuts   string          `json:"shortcuts"`
+	ExportOptions
 }
@@ -0,0 +1,14 @@
+package types
+
+type CreateDriveRequest struct {
+	Name string `json:"name"`
+}
+
+// DriveMembersRequest adds or removes Emails on a shared drive, with
+// ServiceAccounts every service account email is included as well.
+type DriveMembersRequest struct {
+	DriveId         string   `json:"drive_id"`
+	Emails          []string `json:"emails"`
+	ServiceAccounts bool     `json:"service_accounts"`
+	Role            string   `json:"role"`
+}
@@ -0,0 +1,7 @@
+package types
+
+type ExportOptions struct {
+	Export           map[string]string `json:"export"`
+	ExportSkip       []string          `json:"export_skip"`
+	AcknowledgeAbuse bool              `json:"acknowledge_abuse"`
+}
@@ -0,0 +1,12 @@
+package types
+
+type FilterOptions struct {
+	Include        []string `json:"include"`
+	Exclude        []string `json:"exclude"`
+	IncludeRegex   []string `json:"include_regex"`
+	ExcludeRegex   []string `json:"exclude_regex"`
+	MinSize        int64    `json:"min_size"`
+	MaxSize        int64    `json:"max_size"`
+	ModifiedAfter  string   `json:"modified_after"`
+	ModifiedBefore string   `json:"modified_before"`
+}
@@ -4,4 +4,16 @@ type ListFilesRequest struct {
 	Name     string `json:"name"`
 	ParentID string `json:"parent_id"`
 	Count    int    `json:"count"`
+	// Fields selects the Drive file fields, PageSize, PageToken or OrderBy
+	// return a single page with a next_page_token instead of every file.
+	Fields    []string `json:"fields"`
+	PageSize  int      `json:"page_size"`
+	PageToken string   `json:"page_token"`
+	OrderBy   string   `json:"order_by"`
+	// Recursive lists the whole tree below ParentID down to Depth levels,
+	// Output is tree or flat and Format one of json, jsonl or csv.
+	Recursive bool   `json:"recursive"`
+	Depth     int    `json:"depth"`
+	Output    string `json:"output"`
+	Format    string `json:"format"`
 }
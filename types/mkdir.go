@@ -0,0 +1,6 @@
+package types
+
+type MkdirRequest struct {
+	ParentId string `json:"parent_id"`
+	Path     string `json:"path"`
+}
@@ -0,0 +1,15 @@
+package types
+
+type RenameRequest struct {
+	FileId string `json:"file_id"`
+	Name   string `json:"name"`
+}
+
+type MoveRequest struct {
+	FileIds     []string `json:"file_ids"`
+	Query       string   `json:"query"`
+	DesId       string   `json:"des_id"`
+	Concurrency int      `json:"concurrency"`
+	// IncludeTrashed lets Query match trashed items too
+	IncludeTrashed bool `json:"include_trashed"`
+}
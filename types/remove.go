@@ -0,0 +1,8 @@
+package types
+
+type RemoveRequest struct {
+	FileIds     []string `json:"file_ids"`
+	Recursive   bool     `json:"recursive"`
+	Confirm     bool     `json:"confirm"`
+	Concurrency int      `json:"concurrency"`
+}
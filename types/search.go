@@ -0,0 +1,22 @@
+package types
+
+type SearchRequest struct {
+	NameContains   string   `json:"name_contains"`
+	NameEquals     string   `json:"name_equals"`
+	MimeTypes      []string `json:"mime_types"`
+	MinSize        int64    `json:"min_size"`
+	MaxSize        int64    `json:"max_size"`
+	ModifiedAfter  string   `json:"modified_after"`
+	ModifiedBefore string   `json:"modified_before"`
+	// Trashed is exclude, only or include, trashed files are left out by
+	// default
+	Trashed  string `json:"trashed"`
+	Owner    string `json:"owner"`
+	ParentId string `json:"parent_id"`
+	// DriveId searches one whole shared drive, Corpora is passed to Drive
+	// as is
+	DriveId   string `json:"drive_id"`
+	Corpora   string `json:"corpora"`
+	PageSize  int    `json:"page_size"`
+	PageToken string `json:"page_token"`
+}
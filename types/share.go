@@ -0,0 +1,29 @@
+package types
+
+// PermissionOptions grants Role to a user or group by EmailAddress, to a
+// Domain, or to anyone with the link.
+type PermissionOptions struct {
+	Type               string `json:"type"`
+	Role               string `json:"role"`
+	EmailAddress       string `json:"email_address"`
+	Domain             string `json:"domain"`
+	AllowFileDiscovery bool   `json:"allow_file_discovery"`
+	SendNotification   bool   `json:"send_notification"`
+	Message            string `json:"message"`
+}
+
+type CreatePermissionRequest struct {
+	FileId string `json:"file_id"`
+	PermissionOptions
+}
+
+type UpdatePermissionRequest struct {
+	FileId       string `json:"file_id"`
+	PermissionId string `json:"permission_id"`
+	Role         string `json:"role"`
+}
+
+type DeletePermissionRequest struct {
+	FileId       string `json:"file_id"`
+	PermissionId string `json:"permission_id"`
+}
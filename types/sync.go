@@ -0,0 +1,41 @@
+package types
+
+type SyncUploadRequest struct {
+	Path        string              `json:"path"`
+	ParentId    string              `json:"parent_id"`
+	Concurrency int                 `json:"concurrency"`
+	Size        int64               `json:"size"`
+	Verify      bool                `json:"verify"`
+	CompareMd5  bool                `json:"compare_md5"`
+	Delete      bool                `json:"delete"`
+	Filter      FilterOptions       `json:"filter"`
+	DryRun      bool                `json:"dry_run"`
+	Share       []PermissionOptions `json:"share"`
+}
+
+type SyncDownloadRequest struct {
+	FileId      string        `json:"file_id"`
+	LocalDir    string        `json:"local_dir"`
+	Concurrency int           `json:"concurrency"`
+	Size        int64         `json:"size"`
+	Verify      bool          `json:"verify"`
+	CompareMd5  bool          `json:"compare_md5"`
+	Delete      bool          `json:"delete"`
+	Filter      FilterOptions `json:"filter"`
+	DryRun      bool          `json:"dry_run"`
+	Shortcuts   string        `json:"shortcuts"`
+	ExportOptions
+}
+
+type SyncCloneRequest struct {
+	FileId      string              `json:"file_id"`
+	DesId       string              `json:"des_id"`
+	Concurrency int                 `json:"concurrency"`
+	Size        int64               `json:"size"`
+	Verify      bool                `json:"verify"`
+	Delete      bool                `json:"delete"`
+	Filter      FilterOptions       `json:"filter"`
+	DryRun      bool                `json:"dry_run"`
+	Shortcuts   string              `json:"shortcuts"`
+	Share       []PermissionOptions `json:"share"`
+}
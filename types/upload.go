@@ -1,8 +1,15 @@
 package types
 
 type UploadRequest struct {
-	Path        string `json:"path"`
-	ParentId    string `json:"parent_id"`
-	Concurrency int    `json:"concurrency"`
-	Size        int64  `json:"size"`
+	Path        string              `json:"path"`
+	ParentId    string              `json:"parent_id"`
+	Concurrency int                 `json:"concurrency"`
+	Size        int64               `json:"size"`
+	Verify      bool                `json:"verify"`
+	Incremental bool                `json:"incremental"`
+	CompareMd5  bool                `json:"compare_md5"`
+	Conflict    ConflictOptions     `json:"conflict"`
+	Filter      FilterOptions       `json:"filter"`
+	DryRun      bool                `json:"dry_run"`
+	Share       []PermissionOptions `json:"share"`
 }
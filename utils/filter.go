@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"time"
+
+	"github.com/jaskaranSM/transfer-service/types"
+)
+
+// Filter decides which entries of a folder transfer are walked. Globs match
+// either the entry name or its slash separated path below the transfer root,
+// regular expressions match that path. Exclude rules prune whole folders,
+// include, size and time rules only apply to files. A nil Filter matches
+// everything.
+type Filter struct {
+	include        []string
+	exclude        []string
+	includeRegex   []*regexp.Regexp
+	excludeRegex   []*regexp.Regexp
+	minSize        int64
+	maxSize        int64
+	modifiedAfter  time.Time
+	modifiedBefore time.Time
+}
+
+// NewFilter validates opts and returns nil when they hold no rules.
+func NewFilter(opts types.FilterOptions) (*Filter, error) {
+	f := &Filter{
+		include: opts.Include,
+		exclude: opts.Exclude,
+		minSize: opts.MinSize,
+		maxSize: opts.MaxSize,
+	}
+	for _, pattern := range append(append([]string{}, opts.Include...), opts.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+	}
+	var err error
+	f.includeRegex, err = compileRegexps(opts.IncludeRegex)
+	if err != nil {
+		return nil, err
+	}
+	f.excludeRegex, err = compileRegexps(opts.ExcludeRegex)
+	if err != nil {
+		return nil, err
+	}
+	if opts.ModifiedAfter != "" {
+		f.modifiedAfter, err = time.Parse(time.RFC3339, opts.ModifiedAfter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modified_after: %w", err)
+		}
+	}
+	if opts.ModifiedBefore != "" {
+		f.modifiedBefore, err = time.Parse(time.RFC3339, opts.ModifiedBefore)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modified_before: %w", err)
+		}
+	}
+	if f.minSize > 0 && f.maxSize > 0 && f.minSize > f.maxSize {
+		return nil, fmt.Errorf("min_size %d is larger than max_size %d", f.minSize, f.maxSize)
+	}
+	if len(f.include) == 0 && len(f.exclude) == 0 && len(f.includeRegex) == 0 && len(f.excludeRegex) == 0 &&
+		f.minSize == 0 && f.maxSize == 0 && f.modifiedAfter.IsZero() && f.modifiedBefore.IsZero() {
+		return nil, nil
+	}
+	return f, nil
+}
+
+func compileRegexps(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func matchGlobs(patterns []string, relPath string) bool {
+	name := path.Base(relPath)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, relPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRegexps(patterns []*regexp.Regexp, relPath string) bool {
+	for _, re := range patterns {
+		if re.MatchString(relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) excluded(relPath string) bool {
+	return matchGlobs(f.exclude, relPath) || matchRegexps(f.excludeRegex, relPath)
+}
+
+// MatchDir reports whether the folder at relPath should be walked.
+func (f *Filter) MatchDir(relPath string) bool {
+	if f == nil {
+		return true
+	}
+	return !f.excluded(relPath)
+}
+
+// MatchFile reports whether the file at relPath should be transferred. A
+// zero modTime is not checked against the time rules.
+func (f *Filter) MatchFile(relPath string, size int64, modTime time.Time) bool {
+	if f == nil {
+		return true
+	}
+	if f.excluded(relPath) {
+		return false
+	}
+	if len(f.include) != 0 || len(f.includeRegex) != 0 {
+		if !matchGlobs(f.include, relPath) && !matchRegexps(f.includeRegex, relPath) {
+			return false
+		}
+	}
+	if f.minSize > 0 && size < f.minSize {
+		return false
+	}
+	if f.maxSize > 0 && size > f.maxSize {
+		return false
+	}
+	if !modTime.IsZero() {
+		if !f.modifiedAfter.IsZero() && !modTime.After(f.modifiedAfter) {
+			return false
+		}
+		if !f.modifiedBefore.IsZero() && !modTime.Before(f.modifiedBefore) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,269 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jaskaranSM/transfer-service/types"
+)
+
+func TestNewFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    types.FilterOptions
+		wantNil bool
+		wantErr bool
+	}{
+		{
+			name:    "empty options give no filter",
+			wantNil: true,
+		},
+		{
+			name: "glob rule",
+			opts: types.FilterOptions{Include: []string{"*.mkv"}},
+		},
+		{
+			name:    "invalid glob",
+			opts:    types.FilterOptions{Exclude: []string{"[a-"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid regex",
+			opts:    types.FilterOptions{IncludeRegex: []string{"("}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid modified_after",
+			opts:    types.FilterOptions{ModifiedAfter: "yesterday"},
+			wantErr: true,
+		},
+		{
+			name:    "min size above max size",
+			opts:    types.FilterOptions{MinSize: 10, MaxSize: 5},
+			wantErr: true,
+		},
+		{
+			name: "min size equal to max size",
+			opts: types.FilterOptions{MinSize: 5, MaxSize: 5},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := NewFilter(test.opts)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("NewFilter() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if (f == nil) != test.wantNil {
+				t.Errorf("NewFilter() = %v, wantNil %v", f, test.wantNil)
+			}
+		})
+	}
+}
+
+func TestFilterMatchFile(t *testing.T) {
+	after := "2022-01-01T00:00:00Z"
+	before := "2022-02-01T00:00:00Z"
+	at := func(value string) time.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	tests := []struct {
+		name    string
+		opts    types.FilterOptions
+		rel     string
+		size    int64
+		modTime time.Time
+		want    bool
+	}{
+		{
+			name: "empty filter matches everything",
+			rel:  "a/b.txt",
+			want: true,
+		},
+		{
+			name: "include glob matches base name",
+			opts: types.FilterOptions{Include: []string{"*.mkv"}},
+			rel:  "shows/ep1.mkv",
+			want: true,
+		},
+		{
+			name: "include glob misses",
+			opts: types.FilterOptions{Include: []string{"*.mkv"}},
+			rel:  "shows/ep1.srt",
+			want: false,
+		},
+		{
+			name: "include glob matches rel path",
+			opts: types.FilterOptions{Include: []string{"shows/*"}},
+			rel:  "shows/ep1.srt",
+			want: true,
+		},
+		{
+			name: "glob star does not cross folders",
+			opts: types.FilterOptions{Include: []string{"shows/*"}},
+			rel:  "shows/s1/ep1.srt",
+			want: false,
+		},
+		{
+			name: "exclude wins over include",
+			opts: types.FilterOptions{Include: []string{"*.mkv"}, Exclude: []string{"sample*"}},
+			rel:  "shows/sample.mkv",
+			want: false,
+		},
+		{
+			name: "file below excluded folder pattern",
+			opts: types.FilterOptions{Exclude: []string{"extras/*"}},
+			rel:  "extras/a.mkv",
+			want: false,
+		},
+		{
+			name: "include regex matches rel path",
+			opts: types.FilterOptions{IncludeRegex: []string{`^shows/s\d+/`}},
+			rel:  "shows/s1/ep1.mkv",
+			want: true,
+		},
+		{
+			name: "exclude regex matches rel path",
+			opts: types.FilterOptions{ExcludeRegex: []string{`\.part$`}},
+			rel:  "shows/ep1.mkv.part",
+			want: false,
+		},
+		{
+			name: "size at min is kept",
+			opts: types.FilterOptions{MinSize: 100},
+			rel:  "a",
+			size: 100,
+			want: true,
+		},
+		{
+			name: "size below min",
+			opts: types.FilterOptions{MinSize: 100},
+			rel:  "a",
+			size: 99,
+			want: false,
+		},
+		{
+			name: "size at max is kept",
+			opts: types.FilterOptions{MaxSize: 100},
+			rel:  "a",
+			size: 100,
+			want: true,
+		},
+		{
+			name: "size above max",
+			opts: types.FilterOptions{MaxSize: 100},
+			rel:  "a",
+			size: 101,
+			want: false,
+		},
+		{
+			name:    "modified at after bound is dropped",
+			opts:    types.FilterOptions{ModifiedAfter: after},
+			rel:     "a",
+			modTime: at(after),
+			want:    false,
+		},
+		{
+			name:    "modified past after bound",
+			opts:    types.FilterOptions{ModifiedAfter: after},
+			rel:     "a",
+			modTime: at(after).Add(time.Second),
+			want:    true,
+		},
+		{
+			name:    "modified at before bound is dropped",
+			opts:    types.FilterOptions{ModifiedBefore: before},
+			rel:     "a",
+			modTime: at(before),
+			want:    false,
+		},
+		{
+			name:    "modified ahead of before bound",
+			opts:    types.FilterOptions{ModifiedBefore: before},
+			rel:     "a",
+			modTime: at(before).Add(-time.Second),
+			want:    true,
+		},
+		{
+			name: "zero mod time skips time rules",
+			opts: types.FilterOptions{ModifiedAfter: after, ModifiedBefore: before},
+			rel:  "a",
+			want: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := NewFilter(test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := f.MatchFile(test.rel, test.size, test.modTime)
+			if got != test.want {
+				t.Errorf("MatchFile(%q, %d, %v) = %v, want %v", test.rel, test.size, test.modTime, got, test.want)
+			}
+		})
+	}
+}
+
+func TestFilterMatchDir(t *testing.T) {
+	tests := []struct {
+		name string
+		opts types.FilterOptions
+		rel  string
+		want bool
+	}{
+		{
+			name: "empty filter walks everything",
+			rel:  "a/b",
+			want: true,
+		},
+		{
+			name: "exclude glob prunes folder by name",
+			opts: types.FilterOptions{Exclude: []string{"node_modules"}},
+			rel:  "src/node_modules",
+			want: false,
+		},
+		{
+			name: "exclude glob prunes folder by path",
+			opts: types.FilterOptions{Exclude: []string{"src/*"}},
+			rel:  "src/vendor",
+			want: false,
+		},
+		{
+			name: "exclude regex prunes folder",
+			opts: types.FilterOptions{ExcludeRegex: []string{`(^|/)\.git$`}},
+			rel:  "repo/.git",
+			want: false,
+		},
+		{
+			name: "include rules do not prune folders",
+			opts: types.FilterOptions{Include: []string{"*.mkv"}},
+			rel:  "shows",
+			want: true,
+		},
+		{
+			name: "size rules do not prune folders",
+			opts: types.FilterOptions{MinSize: 100},
+			rel:  "shows",
+			want: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f, err := NewFilter(test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := f.MatchDir(test.rel)
+			if got != test.want {
+				t.Errorf("MatchDir(%q) = %v, want %v", test.rel, got, test.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"crypto/md5"
+	"encoding/hex"
+	"io"
 	"math/rand"
 	"net/http"
 	"os"
@@ -37,21 +40,34 @@ func HandleError(ctx *fiber.Ctx, err error) error {
 	return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"Detail": "internal server error"})
 }
 
-func GetFolderSize(filePath string) (int64, error) {
+// GetFolderSize sums the sizes of the files below filePath that filter
+// matches, filter may be nil.
+func GetFolderSize(filePath string, filter *Filter) (int64, error) {
 	var size int64
-	err := filepath.Walk(filePath, func(_ string, info os.FileInfo, err error) error {
+	err := filepath.Walk(filePath, func(walkPath string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() {
+		relPath, err := filepath.Rel(filePath, walkPath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if info.IsDir() {
+			if relPath != "." && !filter.MatchDir(relPath) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filter.MatchFile(relPath, info.Size(), info.ModTime()) {
 			size += info.Size()
 		}
-		return err
+		return nil
 	})
 	return size, err
 }
 
-func GetPathSize(filePath string) (int64, error) {
+func GetPathSize(filePath string, filter *Filter) (int64, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return 0, err
@@ -62,11 +78,25 @@ func GetPathSize(filePath string) (int64, error) {
 		return 0, err
 	}
 	if fileInfo.IsDir() {
-		return GetFolderSize(filePath)
+		return GetFolderSize(filePath, filter)
 	}
 	return fileInfo.Size(), nil
 }
 
+func GetFileMd5(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := md5.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 var letterRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
 
 func RandString(n int) string {